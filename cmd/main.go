@@ -1,29 +1,45 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	_ "github.com/lib/pq"
 
+	"github.com/lightlink/group-service/infrastructure/ws"
 	"github.com/lightlink/group-service/infrastructure/ws/centrifugo"
+	"github.com/lightlink/group-service/infrastructure/ws/embedded"
+	"github.com/lightlink/group-service/infrastructure/ws/eventstream"
+	"github.com/lightlink/group-service/infrastructure/ws/fanout"
 	fileRepository "github.com/lightlink/group-service/internal/file/repository/minio"
 	grpcGroupDelivery "github.com/lightlink/group-service/internal/group/delivery/grpc"
 	httpGroupDelivery "github.com/lightlink/group-service/internal/group/delivery/http"
+	sseGroupDelivery "github.com/lightlink/group-service/internal/group/delivery/sse"
 	groupRepository "github.com/lightlink/group-service/internal/group/repository/postgres"
 	groupUsecase "github.com/lightlink/group-service/internal/group/usecase"
+	grpcMessageDelivery "github.com/lightlink/group-service/internal/message/delivery/grpc"
 	httpMessageDelivery "github.com/lightlink/group-service/internal/message/delivery/http"
 	kafkaMessageFilterDelivery "github.com/lightlink/group-service/internal/message/delivery/kafka"
 	messageHateSpeechRepository "github.com/lightlink/group-service/internal/message/repository/kafka"
 	messageRepository "github.com/lightlink/group-service/internal/message/repository/postgres"
 	messageUsecase "github.com/lightlink/group-service/internal/message/usecase"
+	"github.com/lightlink/group-service/internal/middleware"
 	notificationRepository "github.com/lightlink/group-service/internal/notification/repository/kafka"
+	"github.com/lightlink/group-service/internal/outbox/relay"
+	outboxRepository "github.com/lightlink/group-service/internal/outbox/repository/postgres"
+	presenceRepository "github.com/lightlink/group-service/internal/presence/repository/postgres"
 	proto "github.com/lightlink/group-service/protogen/group"
+	messageProto "github.com/lightlink/group-service/protogen/message"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
 )
 
@@ -34,9 +50,6 @@ func main() {
 	}
 	defer db.Close()
 
-	centrifugoKey := os.Getenv("CENTRIFUGO_HTTP_API_KEY")
-	centrifugoClient := centrifugo.NewCentrifugoClient("http://centrifugo:8000", centrifugoKey)
-
 	// === Repositories ===
 	grpRepo := groupRepository.NewGroupPostgresRepository(db)
 	msgRepo := messageRepository.NewMessagePostgresRepository(db)
@@ -60,18 +73,66 @@ func main() {
 		log.Fatalf("Ошибка инициализации notification repo: %v", err)
 	}
 
+	outboxRepo := outboxRepository.NewOutboxPostgresRepository(db)
+	lastSeenRepo := presenceRepository.NewLastSeenPostgresRepository(db)
+
+	// === Realtime ===
+	centrifugoProxy := middleware.NewCentrifugoProxy(grpRepo, lastSeenRepo)
+	realtimeServer, embeddedServer := newMessagingServer(centrifugoProxy)
+	eventHub := fanout.NewHub(realtimeServer)
+	eventStreams := eventstream.NewServer(eventHub)
+	tokenIssuer := centrifugo.NewTokenIssuer(os.Getenv("TOKEN_KEY"), tokenTTL())
+
+	// === Outbox relay ===
+	outboxRelay := relay.NewRelay(outboxRepo, notifyRepo, msgHateRepo, eventStreams)
+	go outboxRelay.Run(context.Background())
+
 	// === Usecases ===
-	grpUC := groupUsecase.NewGroupUsecase(grpRepo, notifyRepo)
-	msgUC := messageUsecase.NewMessageUsecase(msgRepo, notifyRepo, grpRepo, fileRepo, msgHateRepo, centrifugoClient)
+	grpUC := groupUsecase.NewGroupUsecase(grpRepo, notifyRepo, lastSeenRepo, eventStreams, eventHub, realtimeServer, tokenIssuer)
+	msgUC := messageUsecase.NewMessageUsecase(msgRepo, grpRepo, fileRepo, eventStreams, realtimeServer, outboxRelay)
 
 	// === Запуск gRPC сервера ===
-	go startGRPC(grpUC)
+	go startGRPC(grpUC, msgUC)
+
+	// === Запуск внутреннего HTTP сервера для Centrifugo ===
+	go startInternalHTTP(centrifugoProxy, embeddedServer == nil)
 
 	// === Запуск HTTP сервера ===
-	startHTTP(grpUC, msgUC)
+	startHTTP(grpUC, msgUC, eventStreams, embeddedServer)
+}
+
+// realtimeBackend is what both realtime servers provide on top of publishing.
+type realtimeBackend interface {
+	ws.MessagingServer
+	ws.PresenceProvider
+	ws.StreamPositionProvider
+}
+
+// newMessagingServer picks the realtime backend: REALTIME_BACKEND=embedded runs a centrifuge node
+// in this process, anything else publishes through the Centrifugo server API.
+// The embedded server is returned separately because its WebSocket endpoint has to be mounted.
+func newMessagingServer(centrifugoProxy *middleware.CentrifugoProxy) (realtimeBackend, *embedded.Server) {
+	if os.Getenv("REALTIME_BACKEND") == "embedded" {
+		var allowedOrigins []string
+		if origins := os.Getenv("REALTIME_ALLOWED_ORIGINS"); origins != "" {
+			allowedOrigins = strings.Split(origins, ",")
+		}
+
+		embeddedServer, err := embedded.NewServer(centrifugoProxy.CanSubscribe, centrifugoProxy.RecordActivity, allowedOrigins)
+		if err != nil {
+			log.Fatalf("Ошибка запуска встроенного realtime-сервера: %v", err)
+		}
+		fmt.Println("Realtime: встроенный centrifuge node")
+
+		return embeddedServer, embeddedServer
+	}
+
+	centrifugoKey := os.Getenv("CENTRIFUGO_HTTP_API_KEY")
+	centrifugoClient := centrifugo.NewCentrifugoClient("http://centrifugo:8000", centrifugoKey, centrifugoRetryConfig())
+	return centrifugoClient, nil
 }
 
-func startGRPC(groupUsecase groupUsecase.GroupUsecaseI) {
+func startGRPC(groupUsecase groupUsecase.GroupUsecaseI, messageUsecase messageUsecase.MessageUsecaseI) {
 	listener, err := net.Listen("tcp", ":8084")
 	if err != nil {
 		log.Fatalf("Ошибка при поднятии gRPC listener'a: %v", err)
@@ -80,14 +141,22 @@ func startGRPC(groupUsecase groupUsecase.GroupUsecaseI) {
 	grpcServer := grpc.NewServer()
 	groupService := grpcGroupDelivery.NewGroupService(groupUsecase)
 	proto.RegisterGroupServiceServer(grpcServer, groupService)
+	messageService := grpcMessageDelivery.NewMessageService(messageUsecase)
+	messageProto.RegisterMessageServiceServer(grpcServer, messageService)
 
 	fmt.Println("gRPC сервер запущен на порту :8084")
 	log.Fatal(grpcServer.Serve(listener))
 }
 
-func startHTTP(groupUsecase groupUsecase.GroupUsecaseI, messageUsecase messageUsecase.MessageUsecaseI) {
+func startHTTP(
+	groupUsecase groupUsecase.GroupUsecaseI,
+	messageUsecase messageUsecase.MessageUsecaseI,
+	eventStreams ws.GroupEventSubscriber,
+	embeddedServer *embedded.Server,
+) {
 	groupHandler := httpGroupDelivery.NewGroupHandler(groupUsecase)
 	messageHandler := httpMessageDelivery.NewMessageHandler(messageUsecase)
+	eventStreamHandler := sseGroupDelivery.NewEventStreamHandler(groupUsecase, messageUsecase, eventStreams)
 
 	messageFilterConsumer, err := kafkaMessageFilterDelivery.NewMessageFilterConsumer(
 		messageUsecase, "kafka:29092", "hate-speech-group", "output_hate_speech",
@@ -97,17 +166,105 @@ func startHTTP(groupUsecase groupUsecase.GroupUsecaseI, messageUsecase messageUs
 	}
 	go messageFilterConsumer.Receive()
 
-	router := mux.NewRouter()
+	rootRouter := mux.NewRouter()
+	if embeddedServer != nil {
+		rootRouter.Handle("/connection/websocket", middleware.ValidateAuthWS(embeddedServer.Handler()))
+	}
+
+	router := rootRouter.NewRoute().Subrouter()
+	router.Use(middleware.AuthHTTP(os.Getenv("AUTH_TRUSTED_GATEWAY") == "true"))
 	router.HandleFunc("/api/group/{groupID}/info", groupHandler.InfoHandler).Methods("GET")
+	router.HandleFunc("/api/group/{groupID}/token/refresh", groupHandler.RefreshGroupTokens).Methods("POST")
+	router.HandleFunc("/api/token/refresh", groupHandler.RefreshConnectionToken).Methods("POST")
 	router.HandleFunc("/api/groups", groupHandler.GetGroups).Methods("GET")
 	router.HandleFunc("/api/groups", groupHandler.CreateGroup).Methods("POST")
+	router.HandleFunc("/api/conversations", groupHandler.GetConversations).Methods("GET")
 	router.HandleFunc("/api/get-group-id/{friendID}", groupHandler.GetPersonalGroupID).Methods("GET")
 	router.HandleFunc("/api/group/{groupID}/start-call", groupHandler.StartCall).Methods("POST")
+	router.HandleFunc("/api/group/{groupID}/members", groupHandler.AddMembers).Methods("POST")
+	router.HandleFunc("/api/group/{groupID}/members", groupHandler.RemoveMember).Methods("DELETE")
+	router.HandleFunc("/api/group/{groupID}/members/{userID}", groupHandler.ChangeMemberRole).Methods("PATCH")
+	router.HandleFunc("/api/group/{groupID}/leave", groupHandler.LeaveGroup).Methods("POST")
+	router.HandleFunc("/api/group/{groupID}/read", groupHandler.MarkRead).Methods("POST")
+	router.HandleFunc("/api/group/{groupID}/typing", groupHandler.Typing).Methods("POST")
+	router.HandleFunc("/api/group/{groupID}/presence", groupHandler.GetPresence).Methods("GET")
+	router.HandleFunc("/api/group/{groupID}/events", eventStreamHandler.StreamGroupEvents).Methods("GET")
+	router.HandleFunc("/api/group/{groupID}", groupHandler.RenameGroup).Methods("PATCH")
 	router.HandleFunc("/api/messages/{groupID}", messageHandler.GetGroupMessages).Methods("GET")
+	router.HandleFunc("/api/messages/{groupID}/sync", messageHandler.SyncMessages).Methods("GET")
 	router.HandleFunc("/api/messages", messageHandler.SendMessage).Methods("POST")
+	router.HandleFunc("/api/messages/{messageID}/thread", messageHandler.GetThread).Methods("GET")
+	router.HandleFunc("/api/messages/{messageID}/reactions", messageHandler.AddReaction).Methods("POST")
+	router.HandleFunc("/api/messages/{messageID}/reactions", messageHandler.RemoveReaction).Methods("DELETE")
+	router.HandleFunc("/api/messages/{messageID}", messageHandler.UpdateMessage).Methods("PATCH")
+	router.HandleFunc("/api/messages/{messageID}", messageHandler.DeleteMessage).Methods("DELETE")
 
 	log.Println("starting server at http://127.0.0.1:8080")
-	log.Fatal(http.ListenAndServe(":8080", router))
+	log.Fatal(http.ListenAndServe(":8080", rootRouter))
+}
+
+// PLACEHOLDER_PROXY_SECRET is the value example configs used to ship with; it is public.
+const PLACEHOLDER_PROXY_SECRET = "change-me-proxy-secret"
+
+// startInternalHTTP serves the endpoints that are not behind the user auth middleware: metrics,
+// and with an external Centrifugo the proxy endpoints it calls. They live on a port that is only
+// reachable inside the cluster, and proxy requests must also carry CENTRIFUGO_PROXY_SECRET.
+// Centrifugo gets the same value from its environment, e.g.
+// CENTRIFUGO_CLIENT_PROXY_CONNECT_HTTP_STATIC_HEADERS='{"X-Centrifugo-Proxy-Secret": "..."}',
+// and likewise for the refresh and subscribe proxies.
+func startInternalHTTP(centrifugoProxy *middleware.CentrifugoProxy, serveProxy bool) {
+	router := mux.NewRouter()
+	router.Handle("/metrics", promhttp.Handler()).Methods("GET")
+
+	if serveProxy {
+		switch os.Getenv("CENTRIFUGO_PROXY_SECRET") {
+		case "":
+			log.Fatal("CENTRIFUGO_PROXY_SECRET не задан: Centrifugo должен передавать его в proxy-запросах")
+		case PLACEHOLDER_PROXY_SECRET:
+			log.Fatal("CENTRIFUGO_PROXY_SECRET содержит значение-заглушку: задайте собственный секрет")
+		}
+
+		router.HandleFunc("/centrifugo/connect", centrifugoProxy.Connect).Methods("POST")
+		router.HandleFunc("/centrifugo/refresh", centrifugoProxy.Refresh).Methods("POST")
+		router.HandleFunc("/centrifugo/subscribe", centrifugoProxy.Subscribe).Methods("POST")
+	}
+
+	log.Println("starting internal server at http://127.0.0.1:8081")
+	log.Fatal(http.ListenAndServe(":8081", router))
+}
+
+// centrifugoRetryConfig overrides the defaults with CENTRIFUGO_API_TIMEOUT, CENTRIFUGO_API_MAX_RETRIES,
+// CENTRIFUGO_API_MAX_BACKOFF, CENTRIFUGO_BREAKER_THRESHOLD and CENTRIFUGO_BREAKER_COOLDOWN when set.
+func centrifugoRetryConfig() centrifugo.RetryConfig {
+	retryConfig := centrifugo.DefaultRetryConfig()
+
+	if timeout, err := time.ParseDuration(os.Getenv("CENTRIFUGO_API_TIMEOUT")); err == nil {
+		retryConfig.Timeout = timeout
+	}
+	if maxRetries, err := strconv.Atoi(os.Getenv("CENTRIFUGO_API_MAX_RETRIES")); err == nil && maxRetries >= 0 {
+		retryConfig.MaxRetries = maxRetries
+	}
+	if maxBackoff, err := time.ParseDuration(os.Getenv("CENTRIFUGO_API_MAX_BACKOFF")); err == nil && maxBackoff > 0 {
+		retryConfig.MaxBackoff = maxBackoff
+	}
+	if threshold, err := strconv.Atoi(os.Getenv("CENTRIFUGO_BREAKER_THRESHOLD")); err == nil && threshold > 0 {
+		retryConfig.FailureThreshold = threshold
+	}
+	if cooldown, err := time.ParseDuration(os.Getenv("CENTRIFUGO_BREAKER_COOLDOWN")); err == nil {
+		retryConfig.BreakerCooldown = cooldown
+	}
+
+	return retryConfig
+}
+
+// tokenTTL reads the lifetime of realtime tokens, e.g. CENTRIFUGO_TOKEN_TTL=30m.
+func tokenTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("CENTRIFUGO_TOKEN_TTL"))
+	if err != nil {
+		return centrifugo.DEFAULT_TOKEN_TTL
+	}
+
+	return ttl
 }
 
 func connectToDB() (*sql.DB, error) {
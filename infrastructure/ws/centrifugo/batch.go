@@ -0,0 +1,158 @@
+package centrifugo
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lightlink/group-service/infrastructure/ws"
+)
+
+type BroadcastResult struct {
+	Responses []PublishResponse `json:"responses"`
+}
+
+type BroadcastResponse struct {
+	Result *BroadcastResult `json:"result,omitempty"`
+}
+
+type BatchReply struct {
+	Error     *PublishErrorResponse `json:"error,omitempty"`
+	Broadcast *BroadcastResult      `json:"broadcast,omitempty"`
+}
+
+type BatchResponse struct {
+	Replies []BatchReply `json:"replies"`
+}
+
+type publishRequest struct {
+	Channel        string      `json:"channel"`
+	Data           interface{} `json:"data"`
+	IdempotencyKey string      `json:"idempotency_key,omitempty"`
+}
+
+type broadcastRequest struct {
+	Channels       []string    `json:"channels"`
+	Data           interface{} `json:"data"`
+	IdempotencyKey string      `json:"idempotency_key,omitempty"`
+}
+
+type unsubscribeRequest struct {
+	User    string `json:"user"`
+	Channel string `json:"channel"`
+}
+
+type batchCommand struct {
+	Publish     *publishRequest     `json:"publish,omitempty"`
+	Broadcast   *broadcastRequest   `json:"broadcast,omitempty"`
+	Unsubscribe *unsubscribeRequest `json:"unsubscribe,omitempty"`
+}
+
+func (c *CentrifugoClient) Broadcast(channels []string, data interface{}) error {
+	if len(channels) == 0 {
+		return nil
+	}
+
+	var response BroadcastResponse
+	err := c.callAPI("broadcast", broadcastRequest{
+		Channels:       channels,
+		Data:           data,
+		IdempotencyKey: newIdempotencyKey(),
+	}, &response, 0)
+	if err != nil {
+		return err
+	}
+
+	if response.Result == nil {
+		return nil
+	}
+
+	return broadcastErrors(channels, response.Result.Responses)
+}
+
+// Batch runs the commands in parallel on the Centrifugo side. Publish and broadcast
+// commands without an idempotency key get one, so retrying the batch is safe.
+func (c *CentrifugoClient) Batch(commands []ws.Command) error {
+	if len(commands) == 0 {
+		return nil
+	}
+
+	batchCommands := make([]batchCommand, 0, len(commands))
+	for _, command := range commands {
+		batchCommands = append(batchCommands, toBatchCommand(command))
+	}
+
+	var response BatchResponse
+	err := c.callAPI("batch", map[string]interface{}{
+		"commands": batchCommands,
+		"parallel": true,
+	}, &response, 0)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i, reply := range response.Replies {
+		if reply.Error != nil {
+			errs = append(errs, fmt.Errorf("command %d: %w", i, newAPIError(reply.Error)))
+			continue
+		}
+
+		if reply.Broadcast != nil && i < len(commands) && commands[i].Broadcast != nil {
+			if err := broadcastErrors(commands[i].Broadcast.Channels, reply.Broadcast.Responses); err != nil {
+				errs = append(errs, fmt.Errorf("command %d: %w", i, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func toBatchCommand(command ws.Command) batchCommand {
+	switch {
+	case command.Publish != nil:
+		return batchCommand{Publish: &publishRequest{
+			Channel:        command.Publish.Channel,
+			Data:           command.Publish.Data,
+			IdempotencyKey: idempotencyKeyOrNew(command.Publish.IdempotencyKey),
+		}}
+	case command.Broadcast != nil:
+		return batchCommand{Broadcast: &broadcastRequest{
+			Channels:       command.Broadcast.Channels,
+			Data:           command.Broadcast.Data,
+			IdempotencyKey: idempotencyKeyOrNew(command.Broadcast.IdempotencyKey),
+		}}
+	case command.Unsubscribe != nil:
+		return batchCommand{Unsubscribe: &unsubscribeRequest{
+			User:    command.Unsubscribe.UserID,
+			Channel: command.Unsubscribe.Channel,
+		}}
+	default:
+		return batchCommand{}
+	}
+}
+
+// broadcastErrors reports the channels a broadcast failed for; responses follow the order of channels.
+func broadcastErrors(channels []string, responses []PublishResponse) error {
+	var errs []error
+	for i, response := range responses {
+		if response.Error == nil {
+			continue
+		}
+
+		channel := ""
+		if i < len(channels) {
+			channel = channels[i]
+		}
+		errs = append(errs, fmt.Errorf("channel %s: %w", channel, newAPIError(response.Error)))
+	}
+
+	return errors.Join(errs...)
+}
+
+func idempotencyKeyOrNew(idempotencyKey string) string {
+	if idempotencyKey != "" {
+		return idempotencyKey
+	}
+
+	return newIdempotencyKey()
+}
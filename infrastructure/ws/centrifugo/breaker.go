@@ -0,0 +1,85 @@
+package centrifugo
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("centrifugo circuit breaker is open")
+
+const (
+	breakerClosed = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreaker stops calls to Centrifugo after failureThreshold consecutive failures.
+// After cooldown a single probe call is let through; its outcome closes or reopens the circuit.
+type circuitBreaker struct {
+	failureThreshold int
+	cooldown         time.Duration
+
+	mu               sync.Mutex
+	state            int
+	consecutiveFails int
+	openedAt         time.Time
+	probeInFlight    bool
+}
+
+func newCircuitBreaker(failureThreshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+	}
+}
+
+func (b *circuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.setState(breakerHalfOpen)
+		b.probeInFlight = true
+		return nil
+	case breakerHalfOpen:
+		if b.probeInFlight {
+			return ErrCircuitOpen
+		}
+		b.probeInFlight = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (b *circuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.consecutiveFails = 0
+	b.probeInFlight = false
+	b.setState(breakerClosed)
+}
+
+func (b *circuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.consecutiveFails++
+	b.probeInFlight = false
+
+	if b.state == breakerHalfOpen || b.consecutiveFails >= b.failureThreshold {
+		b.openedAt = time.Now()
+		b.setState(breakerOpen)
+	}
+}
+
+func (b *circuitBreaker) setState(state int) {
+	b.state = state
+	breakerStateGauge.Set(float64(state))
+}
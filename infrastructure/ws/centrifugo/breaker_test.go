@@ -0,0 +1,110 @@
+package centrifugo
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type breakerStep struct {
+	op      string // "allow", "success" or "failure"
+	wantErr error  // checked for "allow" only
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		cooldown  time.Duration
+		steps     []breakerStep
+		wantState int
+	}{
+		{
+			name:      "stays closed below the threshold",
+			threshold: 3,
+			cooldown:  time.Hour,
+			steps: []breakerStep{
+				{op: "allow"}, {op: "failure"},
+				{op: "allow"}, {op: "failure"},
+				{op: "allow"},
+			},
+			wantState: breakerClosed,
+		},
+		{
+			name:      "opens at the threshold",
+			threshold: 2,
+			cooldown:  time.Hour,
+			steps: []breakerStep{
+				{op: "failure"}, {op: "failure"},
+				{op: "allow", wantErr: ErrCircuitOpen},
+			},
+			wantState: breakerOpen,
+		},
+		{
+			name:      "success resets consecutive failures",
+			threshold: 2,
+			cooldown:  time.Hour,
+			steps: []breakerStep{
+				{op: "failure"}, {op: "success"}, {op: "failure"},
+				{op: "allow"},
+			},
+			wantState: breakerClosed,
+		},
+		{
+			name:      "lets a single probe through after the cooldown",
+			threshold: 1,
+			cooldown:  0,
+			steps: []breakerStep{
+				{op: "failure"},
+				{op: "allow"},
+				{op: "allow", wantErr: ErrCircuitOpen},
+			},
+			wantState: breakerHalfOpen,
+		},
+		{
+			name:      "successful probe closes the circuit",
+			threshold: 1,
+			cooldown:  0,
+			steps: []breakerStep{
+				{op: "failure"}, {op: "allow"}, {op: "success"},
+				{op: "allow"},
+			},
+			wantState: breakerClosed,
+		},
+		{
+			name:      "failed probe reopens the circuit",
+			threshold: 3,
+			cooldown:  0,
+			steps: []breakerStep{
+				{op: "failure"}, {op: "failure"}, {op: "failure"},
+				{op: "allow"}, {op: "failure"},
+			},
+			wantState: breakerOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker := newCircuitBreaker(tt.threshold, tt.cooldown)
+
+			for i, step := range tt.steps {
+				switch step.op {
+				case "allow":
+					if err := breaker.Allow(); !errors.Is(err, step.wantErr) {
+						t.Fatalf("step %d: Allow() = %v, want %v", i, err, step.wantErr)
+					}
+				case "success":
+					breaker.Success()
+				case "failure":
+					breaker.Failure()
+				default:
+					t.Fatalf("step %d: unknown op %q", i, step.op)
+				}
+			}
+
+			if breaker.state != tt.wantState {
+				t.Errorf("state = %d, want %d", breaker.state, tt.wantState)
+			}
+		})
+	}
+}
@@ -2,8 +2,14 @@ package centrifugo
 
 import (
 	"bytes"
+	cryptoRand "crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"math/rand"
 	"net/http"
 	"strconv"
 	"time"
@@ -21,70 +27,233 @@ type PublishErrorResponse struct {
 	Message string `json:"message"`
 }
 
+type APIError struct {
+	Code    int
+	Message string
+}
+
+func newAPIError(errorResponse *PublishErrorResponse) *APIError {
+	return &APIError{Code: errorResponse.Code, Message: errorResponse.Message}
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("error: Code %d, Message: %s", e.Code, e.Message)
+}
+
+// PublishSuccessResponse carries the publication's position in the channel history stream;
+// both fields are empty when history is disabled for the namespace.
 type PublishSuccessResponse struct {
-	Offset int    `json:"offset"`
+	Offset uint64 `json:"offset"`
 	Epoch  string `json:"epoch"`
 }
 
+// RetryConfig controls how calls to the Centrifugo server API are retried.
+// MaxRetries is the number of extra attempts after the first one. Only keyed publishes are
+// retried: they come from the outbox relay, which runs off the request path. Every other call
+// serves an HTTP request and makes a single attempt, so an outage cannot hold handlers up.
+type RetryConfig struct {
+	Timeout          time.Duration
+	MaxRetries       int
+	BaseBackoff      time.Duration
+	MaxBackoff       time.Duration
+	FailureThreshold int
+	BreakerCooldown  time.Duration
+}
+
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		Timeout:          5 * time.Second,
+		MaxRetries:       3,
+		BaseBackoff:      100 * time.Millisecond,
+		MaxBackoff:       2 * time.Second,
+		FailureThreshold: 5,
+		BreakerCooldown:  10 * time.Second,
+	}
+}
+
+// Centrifugo reports internal errors with this code; they are worth retrying.
+const internalErrorCode = 100
+
 type CentrifugoClient struct {
-	httpClient *http.Client
-	apiURL     string
-	apiKey     string
+	httpClient  *http.Client
+	apiURL      string
+	apiKey      string
+	retryConfig RetryConfig
+	breaker     *circuitBreaker
 }
 
-func NewCentrifugoClient(apiURL, apiKey string) *CentrifugoClient {
+func NewCentrifugoClient(apiURL, apiKey string, retryConfig RetryConfig) *CentrifugoClient {
 	return &CentrifugoClient{
-		httpClient: &http.Client{Timeout: 5 * time.Second},
-		apiURL:     apiURL,
-		apiKey:     apiKey,
+		httpClient:  &http.Client{Timeout: retryConfig.Timeout},
+		apiURL:      apiURL,
+		apiKey:      apiKey,
+		retryConfig: retryConfig,
+		breaker:     newCircuitBreaker(retryConfig.FailureThreshold, retryConfig.BreakerCooldown),
 	}
 }
 
+// Publish generates an idempotency key, so Centrifugo drops the duplicates our own retries may cause.
 func (c *CentrifugoClient) Publish(channel string, data interface{}) error {
+	return c.publish(channel, data, newIdempotencyKey(), false, 0)
+}
+
+func (c *CentrifugoClient) publish(channel string, data interface{}, idempotencyKey string, skipHistory bool, maxRetries int) error {
 	payload := map[string]interface{}{
+		"channel":         channel,
+		"data":            data,
+		"idempotency_key": idempotencyKey,
+	}
+	if skipHistory {
+		payload["skip_history"] = true
+	}
+
+	return c.callAPI("publish", payload, nil, maxRetries)
+}
+
+// Unsubscribe drops the user's subscription to the channel on every connection.
+func (c *CentrifugoClient) Unsubscribe(userID string, channel string) error {
+	payload := map[string]interface{}{
+		"user":    userID,
 		"channel": channel,
-		"data":    data,
 	}
 
+	return c.callAPI("unsubscribe", payload, nil, 0)
+}
+
+// callAPI decodes the response body into response when it is not nil. Retryable failures
+// are retried up to maxRetries times with backoff.
+func (c *CentrifugoClient) callAPI(method string, payload interface{}, response interface{}, maxRetries int) error {
+	startedAt := time.Now()
+	defer func() {
+		apiRequestDuration.WithLabelValues(method).Observe(time.Since(startedAt).Seconds())
+	}()
+
 	jsonPayload, err := json.Marshal(payload)
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequest("POST", c.apiURL+"/api/publish", bytes.NewBuffer(jsonPayload))
-	if err != nil {
+	if err := c.breaker.Allow(); err != nil {
+		apiRequestsTotal.WithLabelValues(method, "circuit_open").Inc()
 		return err
 	}
 
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			time.Sleep(c.backoff(attempt))
+		}
+
+		var retryable bool
+		retryable, err = c.doAPICall(method, jsonPayload, response)
+		if err == nil {
+			break
+		}
+
+		apiAttemptFailuresTotal.WithLabelValues(method).Inc()
+		if !retryable || attempt >= maxRetries {
+			break
+		}
+		log.Printf("ERR: Centrifugo %s failed (attempt %d), retrying: %v\n", method, attempt+1, err)
+	}
+
+	if err != nil {
+		apiRequestsTotal.WithLabelValues(method, "error").Inc()
+	} else {
+		apiRequestsTotal.WithLabelValues(method, "success").Inc()
+	}
+
+	// Rejected requests still prove that Centrifugo is up, only unavailability opens the circuit.
+	var apiErr *APIError
+	if err == nil || (errors.As(err, &apiErr) && apiErr.Code != internalErrorCode) {
+		c.breaker.Success()
+	} else {
+		c.breaker.Failure()
+	}
+
+	return err
+}
+
+// doAPICall makes a single attempt and reports whether a failure may succeed on retry.
+func (c *CentrifugoClient) doAPICall(method string, jsonPayload []byte, response interface{}) (bool, error) {
+	req, err := http.NewRequest("POST", c.apiURL+"/api/"+method, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return false, err
+	}
+
 	req.Header.Set("X-API-Key", c.apiKey)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return err
+		return true, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("centrifugo error: %s", resp.Status)
+		retryable := resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+		return retryable, fmt.Errorf("centrifugo error: %s", resp.Status)
 	}
 
-	var publishResponse PublishResponse
-	err = json.NewDecoder(resp.Body).Decode(&publishResponse)
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to decode response: %w", err)
+		return true, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	if publishResponse.Error != nil {
-		return fmt.Errorf("error: Code %d, Message: %s", publishResponse.Error.Code, publishResponse.Error.Message)
+	var apiResponse PublishResponse
+	err = json.Unmarshal(body, &apiResponse)
+	if err != nil {
+		return true, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	fmt.Println("Successfully published in channel")
+	if apiResponse.Error != nil {
+		apiErr := newAPIError(apiResponse.Error)
+		return apiErr.Code == internalErrorCode, apiErr
+	}
 
-	return nil
+	if response != nil {
+		if err := json.Unmarshal(body, response); err != nil {
+			return false, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+
+	return false, nil
+}
+
+// backoff is an exponential delay with full jitter.
+func (c *CentrifugoClient) backoff(attempt int) time.Duration {
+	delay := c.retryConfig.BaseBackoff << (attempt - 1)
+	if delay <= 0 || delay > c.retryConfig.MaxBackoff {
+		delay = c.retryConfig.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(delay)) + 1)
+}
+
+func newIdempotencyKey() string {
+	key := make([]byte, 16)
+	if _, err := cryptoRand.Read(key); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+
+	return hex.EncodeToString(key)
 }
 
 func (c *CentrifugoClient) PublishToGroup(groupID uint, data interface{}) error {
 	groupIDString := strconv.FormatUint(uint64(groupID), 10)
 	return c.Publish(groupEntity.GroupChannel(groupIDString), data)
 }
+
+// PublishToGroupWithKey lets callers that retry on their own, like the outbox relay,
+// reuse one idempotency key across attempts.
+func (c *CentrifugoClient) PublishToGroupWithKey(groupID uint, data interface{}, idempotencyKey string) error {
+	groupIDString := strconv.FormatUint(uint64(groupID), 10)
+	return c.publish(groupEntity.GroupChannel(groupIDString), data, idempotencyKey, false, c.retryConfig.MaxRetries)
+}
+
+func (c *CentrifugoClient) PublishEphemeralToGroup(groupID uint, data interface{}) error {
+	groupIDString := strconv.FormatUint(uint64(groupID), 10)
+	return c.publish(groupEntity.GroupChannel(groupIDString), data, newIdempotencyKey(), true, 0)
+}
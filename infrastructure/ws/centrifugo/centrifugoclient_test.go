@@ -0,0 +1,42 @@
+package centrifugo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffLimits(t *testing.T) {
+	defaultConfig := RetryConfig{
+		BaseBackoff:      100 * time.Millisecond,
+		MaxBackoff:       time.Second,
+		FailureThreshold: 1,
+	}
+
+	tests := []struct {
+		name     string
+		config   RetryConfig
+		attempt  int
+		wantUpTo time.Duration
+	}{
+		{name: "first retry uses the base delay", config: defaultConfig, attempt: 1, wantUpTo: 100 * time.Millisecond},
+		{name: "delay doubles", config: defaultConfig, attempt: 2, wantUpTo: 200 * time.Millisecond},
+		{name: "delay doubles again", config: defaultConfig, attempt: 4, wantUpTo: 800 * time.Millisecond},
+		{name: "delay is capped", config: defaultConfig, attempt: 5, wantUpTo: time.Second},
+		{name: "overflowing shift is capped", config: defaultConfig, attempt: 70, wantUpTo: time.Second},
+		{name: "zero backoff does not wait", config: RetryConfig{FailureThreshold: 1}, attempt: 1, wantUpTo: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewCentrifugoClient("", "", tt.config)
+
+			// Jitter makes every call different, so check the bounds on many of them.
+			for i := 0; i < 1000; i++ {
+				delay := client.backoff(tt.attempt)
+				if delay < 0 || delay > tt.wantUpTo || (tt.wantUpTo > 0 && delay == 0) {
+					t.Fatalf("backoff(%d) = %v, want within (0, %v]", tt.attempt, delay, tt.wantUpTo)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package centrifugo
+
+import "github.com/lightlink/group-service/infrastructure/ws"
+
+type HistoryResult struct {
+	Offset uint64 `json:"offset"`
+	Epoch  string `json:"epoch"`
+}
+
+type HistoryResponse struct {
+	Result *HistoryResult `json:"result,omitempty"`
+}
+
+// StreamPosition requires history to be enabled on the channel's namespace. With limit 0
+// Centrifugo returns no publications, only the current offset and epoch.
+func (c *CentrifugoClient) StreamPosition(channel string) (ws.StreamPosition, error) {
+	var response HistoryResponse
+	err := c.callAPI("history", map[string]interface{}{
+		"channel": channel,
+		"limit":   0,
+	}, &response, 0)
+	if err != nil {
+		return ws.StreamPosition{}, err
+	}
+
+	if response.Result == nil {
+		return ws.StreamPosition{}, nil
+	}
+
+	return ws.StreamPosition{
+		Offset: response.Result.Offset,
+		Epoch:  response.Result.Epoch,
+	}, nil
+}
@@ -0,0 +1,29 @@
+package centrifugo
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	apiRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "centrifugo_api_requests_total",
+		Help: "Centrifugo server API calls by method and outcome (success, error, circuit_open).",
+	}, []string{"method", "result"})
+
+	apiAttemptFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "centrifugo_api_attempt_failures_total",
+		Help: "Failed Centrifugo server API attempts, including the ones that were retried.",
+	}, []string{"method"})
+
+	apiRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "centrifugo_api_request_duration_seconds",
+		Help:    "Duration of Centrifugo server API calls including retries.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+
+	breakerStateGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "centrifugo_circuit_breaker_state",
+		Help: "Circuit breaker state: 0 closed, 1 open, 2 half-open.",
+	})
+)
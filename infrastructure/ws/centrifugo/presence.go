@@ -0,0 +1,41 @@
+package centrifugo
+
+type ClientInfo struct {
+	User   string `json:"user"`
+	Client string `json:"client"`
+}
+
+type PresenceResult struct {
+	Presence map[string]ClientInfo `json:"presence"`
+}
+
+type PresenceResponse struct {
+	Result *PresenceResult `json:"result,omitempty"`
+}
+
+// PresenceUserIDs requires presence to be enabled on the channel's namespace.
+func (c *CentrifugoClient) PresenceUserIDs(channel string) ([]string, error) {
+	var response PresenceResponse
+	err := c.callAPI("presence", map[string]interface{}{
+		"channel": channel,
+	}, &response, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Result == nil {
+		return nil, nil
+	}
+
+	userIDs := make([]string, 0, len(response.Result.Presence))
+	seen := make(map[string]bool, len(response.Result.Presence))
+	for _, info := range response.Result.Presence {
+		if info.User == "" || seen[info.User] {
+			continue
+		}
+		seen[info.User] = true
+		userIDs = append(userIDs, info.User)
+	}
+
+	return userIDs, nil
+}
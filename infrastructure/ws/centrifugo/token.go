@@ -0,0 +1,45 @@
+package centrifugo
+
+import (
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// DEFAULT_TOKEN_TTL matches the proxy refresh interval: clients refresh the token on
+// expiry, and that refresh is what keeps last-seen of token-based connections current.
+const DEFAULT_TOKEN_TTL = time.Minute
+
+type TokenIssuer struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewTokenIssuer(secret string, ttl time.Duration) *TokenIssuer {
+	if ttl <= 0 {
+		ttl = DEFAULT_TOKEN_TTL
+	}
+
+	return &TokenIssuer{
+		secret: []byte(secret),
+		ttl:    ttl,
+	}
+}
+
+// ConnectionToken only authenticates the connection; every subscription goes through
+// the subscribe proxy, which checks current membership.
+func (i *TokenIssuer) ConnectionToken(userID string) (string, time.Time, error) {
+	expiresAt := time.Now().Add(i.ttl)
+
+	token, err := i.sign(jwt.MapClaims{
+		"sub": userID,
+		"exp": expiresAt.Unix(),
+	})
+
+	return token, expiresAt, err
+}
+
+func (i *TokenIssuer) sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(i.secret)
+}
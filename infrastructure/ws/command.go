@@ -0,0 +1,25 @@
+package ws
+
+// Command is one operation of a Batch; exactly one of its fields is set.
+type Command struct {
+	Publish     *PublishCommand
+	Broadcast   *BroadcastCommand
+	Unsubscribe *UnsubscribeCommand
+}
+
+type PublishCommand struct {
+	Channel        string
+	Data           interface{}
+	IdempotencyKey string
+}
+
+type BroadcastCommand struct {
+	Channels       []string
+	Data           interface{}
+	IdempotencyKey string
+}
+
+type UnsubscribeCommand struct {
+	UserID  string
+	Channel string
+}
@@ -0,0 +1,251 @@
+package embedded
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/centrifugal/centrifuge"
+	"github.com/lightlink/group-service/infrastructure/ws"
+	groupEntity "github.com/lightlink/group-service/internal/group/domain/entity"
+)
+
+// Mirrors history_size and history_ttl of the group_messages namespace in centrifugo.json.
+const (
+	GROUP_HISTORY_SIZE = 1000
+	GROUP_HISTORY_TTL  = 24 * time.Hour
+)
+
+// SubscribeAuthorizer decides whether the user may subscribe to the channel.
+type SubscribeAuthorizer func(userID, channel string) (bool, error)
+
+// ActivityRecorder is told when a user connects or disconnects, e.g. to keep last-seen up to date.
+type ActivityRecorder func(userID string)
+
+// Server is a MessagingServer backed by a centrifuge node running in this process.
+// Clients connect to Handler, which expects credentials set by middleware.ValidateAuthWS.
+type Server struct {
+	node    *centrifuge.Node
+	handler http.Handler
+}
+
+func NewServer(authorizeSubscribe SubscribeAuthorizer, recordActivity ActivityRecorder, allowedOrigins []string) (*Server, error) {
+	node, err := centrifuge.New(centrifuge.Config{
+		LogLevel: centrifuge.LogLevelInfo,
+		LogHandler: func(entry centrifuge.LogEntry) {
+			log.Printf("centrifuge: %s %v\n", entry.Message, entry.Fields)
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create centrifuge node: %w", err)
+	}
+
+	node.OnConnect(func(client *centrifuge.Client) {
+		recordActivity(client.UserID())
+
+		client.OnDisconnect(func(e centrifuge.DisconnectEvent) {
+			recordActivity(client.UserID())
+		})
+
+		client.OnSubscribe(func(e centrifuge.SubscribeEvent, cb centrifuge.SubscribeCallback) {
+			allowed, err := authorizeSubscribe(client.UserID(), e.Channel)
+			if err != nil {
+				log.Printf("ERR: Failed to authorize subscription to %s: %v\n", e.Channel, err)
+				cb(centrifuge.SubscribeReply{}, centrifuge.ErrorInternal)
+				return
+			}
+
+			if !allowed {
+				cb(centrifuge.SubscribeReply{}, centrifuge.ErrorPermissionDenied)
+				return
+			}
+
+			cb(centrifuge.SubscribeReply{
+				Options: centrifuge.SubscribeOptions{
+					EmitPresence:   true,
+					EnableRecovery: historyEnabled(e.Channel),
+				},
+			}, nil)
+		})
+
+		// Mirrors allow_publish_for_subscriber of the room and group namespaces in centrifugo.json.
+		client.OnPublish(func(e centrifuge.PublishEvent, cb centrifuge.PublishCallback) {
+			if !clientPublishAllowed(e.Channel) {
+				cb(centrifuge.PublishReply{}, centrifuge.ErrorPermissionDenied)
+				return
+			}
+
+			allowed, err := authorizeSubscribe(client.UserID(), e.Channel)
+			if err != nil || !allowed {
+				cb(centrifuge.PublishReply{}, centrifuge.ErrorPermissionDenied)
+				return
+			}
+
+			cb(centrifuge.PublishReply{}, nil)
+		})
+	})
+
+	if err := node.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run centrifuge node: %w", err)
+	}
+
+	websocketConfig := centrifuge.WebsocketConfig{
+		ReadBufferSize:     1024,
+		UseWriteBufferPool: true,
+	}
+	if len(allowedOrigins) > 0 {
+		websocketConfig.CheckOrigin = func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			for _, allowedOrigin := range allowedOrigins {
+				if origin == allowedOrigin {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	return &Server{
+		node:    node,
+		handler: centrifuge.NewWebsocketHandler(node, websocketConfig),
+	}, nil
+}
+
+func (s *Server) Handler() http.Handler {
+	return s.handler
+}
+
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.node.Shutdown(ctx)
+}
+
+func (s *Server) PresenceUserIDs(channel string) ([]string, error) {
+	result, err := s.node.Presence(channel)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]string, 0, len(result.Presence))
+	seen := make(map[string]bool, len(result.Presence))
+	for _, info := range result.Presence {
+		if info.UserID == "" || seen[info.UserID] {
+			continue
+		}
+		seen[info.UserID] = true
+		userIDs = append(userIDs, info.UserID)
+	}
+
+	return userIDs, nil
+}
+
+// StreamPosition with limit 0 reads no publications, only the current offset and epoch.
+func (s *Server) StreamPosition(channel string) (ws.StreamPosition, error) {
+	result, err := s.node.History(channel, centrifuge.WithLimit(0))
+	if err != nil {
+		return ws.StreamPosition{}, err
+	}
+
+	return ws.StreamPosition{
+		Offset: result.Offset,
+		Epoch:  result.Epoch,
+	}, nil
+}
+
+func (s *Server) Publish(channel string, data interface{}) error {
+	return s.publish(channel, data)
+}
+
+func (s *Server) PublishToGroup(groupID uint, data interface{}) error {
+	return s.publish(groupChannel(groupID), data)
+}
+
+func (s *Server) PublishToGroupWithKey(groupID uint, data interface{}, idempotencyKey string) error {
+	return s.publish(groupChannel(groupID), data, centrifuge.WithIdempotencyKey(idempotencyKey))
+}
+
+func (s *Server) PublishEphemeralToGroup(groupID uint, data interface{}) error {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.node.Publish(groupChannel(groupID), jsonData)
+	return err
+}
+
+func (s *Server) Unsubscribe(userID string, channel string) error {
+	return s.node.Unsubscribe(userID, channel)
+}
+
+// Broadcast publishes channel by channel; there is no network round trip to save in-process.
+func (s *Server) Broadcast(channels []string, data interface{}) error {
+	var errs []error
+	for _, channel := range channels {
+		if err := s.publish(channel, data); err != nil {
+			errs = append(errs, fmt.Errorf("channel %s: %w", channel, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *Server) Batch(commands []ws.Command) error {
+	var errs []error
+	for i, command := range commands {
+		var err error
+		switch {
+		case command.Publish != nil:
+			err = s.publish(command.Publish.Channel, command.Publish.Data, idempotencyOptions(command.Publish.IdempotencyKey)...)
+		case command.Broadcast != nil:
+			err = s.Broadcast(command.Broadcast.Channels, command.Broadcast.Data)
+		case command.Unsubscribe != nil:
+			err = s.Unsubscribe(command.Unsubscribe.UserID, command.Unsubscribe.Channel)
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("command %d: %w", i, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *Server) publish(channel string, data interface{}, opts ...centrifuge.PublishOption) error {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if historyEnabled(channel) {
+		opts = append(opts, centrifuge.WithHistory(GROUP_HISTORY_SIZE, GROUP_HISTORY_TTL))
+	}
+
+	_, err = s.node.Publish(channel, jsonData, opts...)
+	return err
+}
+
+func idempotencyOptions(idempotencyKey string) []centrifuge.PublishOption {
+	if idempotencyKey == "" {
+		return nil
+	}
+
+	return []centrifuge.PublishOption{centrifuge.WithIdempotencyKey(idempotencyKey)}
+}
+
+func clientPublishAllowed(channel string) bool {
+	return strings.HasPrefix(channel, "room:") || strings.HasPrefix(channel, "group:")
+}
+
+func historyEnabled(channel string) bool {
+	return strings.HasPrefix(channel, "group_messages:")
+}
+
+func groupChannel(groupID uint) string {
+	return groupEntity.GroupChannel(strconv.FormatUint(uint64(groupID), 10))
+}
@@ -0,0 +1,81 @@
+package eventstream
+
+import (
+	"github.com/lightlink/group-service/infrastructure/ws"
+	"github.com/lightlink/group-service/infrastructure/ws/fanout"
+)
+
+// Server is the MessagingServer decorator behind the SSE transport: it passes every call on
+// to next and fans group publishes out to the event streams of this process. It keeps its own
+// subscribers, so browser streams and gRPC streams are buffered and dropped independently.
+type Server struct {
+	*fanout.Subscribers
+
+	next ws.MessagingServer
+}
+
+func NewServer(next ws.MessagingServer) *Server {
+	return &Server{
+		Subscribers: fanout.NewSubscribers(),
+		next:        next,
+	}
+}
+
+func (s *Server) Publish(channel string, data interface{}) error {
+	err := s.next.Publish(channel, data)
+	s.DispatchToChannels([]string{channel}, data)
+
+	return err
+}
+
+func (s *Server) PublishToGroup(groupID uint, data interface{}) error {
+	err := s.next.PublishToGroup(groupID, data)
+	s.Dispatch(groupID, data)
+
+	return err
+}
+
+// PublishToGroupWithKey dispatches only once next accepted the publication, since keyed
+// callers retry failures.
+func (s *Server) PublishToGroupWithKey(groupID uint, data interface{}, idempotencyKey string) error {
+	if err := s.next.PublishToGroupWithKey(groupID, data, idempotencyKey); err != nil {
+		return err
+	}
+
+	s.Dispatch(groupID, data)
+
+	return nil
+}
+
+func (s *Server) PublishEphemeralToGroup(groupID uint, data interface{}) error {
+	err := s.next.PublishEphemeralToGroup(groupID, data)
+	s.Dispatch(groupID, data)
+
+	return err
+}
+
+func (s *Server) Broadcast(channels []string, data interface{}) error {
+	err := s.next.Broadcast(channels, data)
+	s.DispatchToChannels(channels, data)
+
+	return err
+}
+
+func (s *Server) Batch(commands []ws.Command) error {
+	err := s.next.Batch(commands)
+
+	for _, command := range commands {
+		switch {
+		case command.Publish != nil:
+			s.DispatchToChannels([]string{command.Publish.Channel}, command.Publish.Data)
+		case command.Broadcast != nil:
+			s.DispatchToChannels(command.Broadcast.Channels, command.Broadcast.Data)
+		}
+	}
+
+	return err
+}
+
+func (s *Server) Unsubscribe(userID string, channel string) error {
+	return s.next.Unsubscribe(userID, channel)
+}
@@ -0,0 +1,79 @@
+package fanout
+
+import (
+	"github.com/lightlink/group-service/infrastructure/ws"
+)
+
+// Hub wraps a MessagingServer and copies every group publish to in-process subscribers,
+// so gRPC streams see the same signals as Centrifugo clients.
+type Hub struct {
+	*Subscribers
+
+	next ws.MessagingServer
+}
+
+func NewHub(next ws.MessagingServer) *Hub {
+	return &Hub{
+		Subscribers: NewSubscribers(),
+		next:        next,
+	}
+}
+
+func (h *Hub) Publish(channel string, data interface{}) error {
+	err := h.next.Publish(channel, data)
+	h.DispatchToChannels([]string{channel}, data)
+
+	return err
+}
+
+func (h *Hub) PublishToGroup(groupID uint, data interface{}) error {
+	err := h.next.PublishToGroup(groupID, data)
+	h.Dispatch(groupID, data)
+
+	return err
+}
+
+// PublishToGroupWithKey dispatches locally only once next accepted the publication: keyed
+// callers retry failures, and the idempotency key only dedupes on the next server's side.
+func (h *Hub) PublishToGroupWithKey(groupID uint, data interface{}, idempotencyKey string) error {
+	if err := h.next.PublishToGroupWithKey(groupID, data, idempotencyKey); err != nil {
+		return err
+	}
+
+	h.Dispatch(groupID, data)
+
+	return nil
+}
+
+func (h *Hub) PublishEphemeralToGroup(groupID uint, data interface{}) error {
+	err := h.next.PublishEphemeralToGroup(groupID, data)
+	h.Dispatch(groupID, data)
+
+	return err
+}
+
+func (h *Hub) Broadcast(channels []string, data interface{}) error {
+	err := h.next.Broadcast(channels, data)
+	h.DispatchToChannels(channels, data)
+
+	return err
+}
+
+func (h *Hub) Batch(commands []ws.Command) error {
+	err := h.next.Batch(commands)
+
+	for _, command := range commands {
+		switch {
+		case command.Publish != nil:
+			h.DispatchToChannels([]string{command.Publish.Channel}, command.Publish.Data)
+		case command.Broadcast != nil:
+			h.DispatchToChannels(command.Broadcast.Channels, command.Broadcast.Data)
+		}
+	}
+
+	return err
+}
+
+func (h *Hub) Unsubscribe(userID string, channel string) error {
+	return h.next.Unsubscribe(userID, channel)
+}
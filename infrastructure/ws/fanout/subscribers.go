@@ -0,0 +1,131 @@
+package fanout
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lightlink/group-service/infrastructure/ws"
+	groupEntity "github.com/lightlink/group-service/internal/group/domain/entity"
+)
+
+const SUBSCRIBER_BUFFER_SIZE = 64
+
+// Subscribers holds the in-process subscribers of group signals. The MessagingServer
+// decorators that fan publishes out locally each keep their own.
+type Subscribers struct {
+	mu          sync.Mutex
+	subscribers map[uint]map[chan ws.Event]struct{}
+}
+
+func NewSubscribers() *Subscribers {
+	return &Subscribers{
+		subscribers: make(map[uint]map[chan ws.Event]struct{}),
+	}
+}
+
+func (s *Subscribers) SubscribeGroup(groupID uint) (<-chan ws.Event, func()) {
+	events := make(chan ws.Event, SUBSCRIBER_BUFFER_SIZE)
+
+	s.mu.Lock()
+	if s.subscribers[groupID] == nil {
+		s.subscribers[groupID] = make(map[chan ws.Event]struct{})
+	}
+	s.subscribers[groupID][events] = struct{}{}
+	s.mu.Unlock()
+
+	unsubscribe := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.removeLocked(groupID, events)
+	}
+
+	return events, unsubscribe
+}
+
+// DispatchToChannels dispatches to the groups of the group channels among channels.
+func (s *Subscribers) DispatchToChannels(channels []string, data interface{}) {
+	for _, channel := range channels {
+		if groupID, ok := parseGroupChannel(channel); ok {
+			s.Dispatch(groupID, data)
+		}
+	}
+}
+
+func (s *Subscribers) Dispatch(groupID uint, data interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.subscribers[groupID]) == 0 {
+		return
+	}
+
+	event, err := newEvent(groupID, data)
+	if err != nil {
+		log.Printf("ERR: Failed to encode event for group %d subscribers: %v\n", groupID, err)
+		return
+	}
+
+	for events := range s.subscribers[groupID] {
+		select {
+		case events <- event:
+		default:
+			// Publishers must never block on a slow stream, so it is dropped instead.
+			log.Printf("ERR: Dropping slow subscriber of group %d\n", groupID)
+			s.removeLocked(groupID, events)
+		}
+	}
+}
+
+func (s *Subscribers) removeLocked(groupID uint, events chan ws.Event) {
+	if _, ok := s.subscribers[groupID][events]; !ok {
+		return
+	}
+
+	delete(s.subscribers[groupID], events)
+	close(events)
+
+	if len(s.subscribers[groupID]) == 0 {
+		delete(s.subscribers, groupID)
+	}
+}
+
+func newEvent(groupID uint, data interface{}) (ws.Event, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return ws.Event{}, err
+	}
+
+	var signal struct {
+		Type    string          `json:"type"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(payload, &signal); err != nil || signal.Type == "" {
+		// Not a typed signal: pass the whole publication through as the payload.
+		signal.Payload = payload
+	}
+
+	return ws.Event{
+		GroupID:     groupID,
+		Type:        signal.Type,
+		Payload:     signal.Payload,
+		PublishedAt: time.Now(),
+	}, nil
+}
+
+func parseGroupChannel(channel string) (uint, bool) {
+	prefix := groupEntity.GroupChannel("")
+	if !strings.HasPrefix(channel, prefix) {
+		return 0, false
+	}
+
+	groupID, err := strconv.ParseUint(strings.TrimPrefix(channel, prefix), 10, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	return uint(groupID), true
+}
@@ -0,0 +1,13 @@
+package ws
+
+// StreamPosition is a point in a channel's history stream, as used by Centrifugo recovery.
+type StreamPosition struct {
+	Offset uint64
+	Epoch  string
+}
+
+// StreamPositionProvider reports the current top of a channel's history stream. A client that
+// subscribes recovering from this position receives everything published after it.
+type StreamPositionProvider interface {
+	StreamPosition(channel string) (StreamPosition, error)
+}
@@ -3,4 +3,14 @@ package ws
 type MessagingServer interface {
 	Publish(channel string, data interface{}) error
 	PublishToGroup(groupID uint, data interface{}) error
+	// PublishToGroupWithKey publishes at most once per idempotency key within the server's dedup window.
+	PublishToGroupWithKey(groupID uint, data interface{}, idempotencyKey string) error
+	// PublishEphemeralToGroup skips the channel history, for signals like typing that are
+	// worthless after a reconnect and must not push messages out of the recovery window.
+	PublishEphemeralToGroup(groupID uint, data interface{}) error
+	Unsubscribe(userID string, channel string) error
+	// Broadcast publishes the same data to every channel in a single request.
+	Broadcast(channels []string, data interface{}) error
+	// Batch sends several commands in a single request; it fails if any of them fails.
+	Batch(commands []Command) error
 }
@@ -0,0 +1,7 @@
+package ws
+
+// PresenceProvider reports who is currently subscribed to a channel.
+type PresenceProvider interface {
+	// PresenceUserIDs returns the distinct ids of users with at least one subscribed connection.
+	PresenceUserIDs(channel string) ([]string, error)
+}
@@ -0,0 +1,20 @@
+package ws
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event is a signal published to a group, as seen by in-process subscribers.
+type Event struct {
+	GroupID     uint
+	Type        string
+	Payload     json.RawMessage
+	PublishedAt time.Time
+}
+
+// GroupEventSubscriber delivers the signals published to a group. The events channel is
+// closed when unsubscribe is called or when the subscriber falls too far behind.
+type GroupEventSubscriber interface {
+	SubscribeGroup(groupID uint) (events <-chan Event, unsubscribe func())
+}
@@ -0,0 +1,8 @@
+package ws
+
+import "time"
+
+// TokenIssuer signs the tokens clients present to the realtime server.
+type TokenIssuer interface {
+	ConnectionToken(userID string) (token string, expiresAt time.Time, err error)
+}
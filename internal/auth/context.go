@@ -0,0 +1,15 @@
+package auth
+
+import "context"
+
+type userIDContextKey struct{}
+
+func WithUserID(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, userIDContextKey{}, userID)
+}
+
+// UserIDFromContext returns the id of the user authenticated by the HTTP auth middleware.
+func UserIDFromContext(ctx context.Context) (uint, bool) {
+	userID, ok := ctx.Value(userIDContextKey{}).(uint)
+	return userID, ok
+}
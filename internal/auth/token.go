@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var (
+	ErrInvalidToken  = errors.New("token is invalid")
+	ErrMissingUserID = errors.New("user claims are missing")
+	errBadSignMethod = errors.New("bad sign method")
+)
+
+// ParseAccessToken validates an HS256 access_token issued by the auth service
+// and returns the user id from its "user" claim.
+func ParseAccessToken(tokenString string, key []byte) (string, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		method, ok := token.Method.(*jwt.SigningMethodHMAC)
+		if !ok || method.Alg() != "HS256" {
+			return nil, errBadSignMethod
+		}
+		return key, nil
+	})
+	if err != nil || !token.Valid {
+		return "", ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", ErrInvalidToken
+	}
+
+	claimsUser, ok := claims["user"].(map[string]interface{})
+	if !ok {
+		return "", ErrMissingUserID
+	}
+
+	userIDString, ok := claimsUser["id"].(string)
+	if !ok || userIDString == "" {
+		return "", ErrMissingUserID
+	}
+
+	return userIDString, nil
+}
@@ -84,3 +84,11 @@ func (r *FileRepository) GetPresignedURL(
 
 	return strings.Replace(presignedURL.String(), "http://group-service-minio:9000", "http://localhost/minio", 1), nil
 }
+
+func (r *FileRepository) DeleteObject(objectName string) error {
+	if err := r.client.RemoveObject(r.bucketName, objectName); err != nil {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+
+	return nil
+}
@@ -8,4 +8,5 @@ import (
 type FileRepositoryI interface {
 	UploadObject(objectName string, reader io.Reader, size int64, contentType string) error
 	GetPresignedURL(objectName string, expiry time.Duration) (string, error)
+	DeleteObject(objectName string) error
 }
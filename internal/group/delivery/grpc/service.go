@@ -2,11 +2,15 @@ package grpc
 
 import (
 	"context"
+	"errors"
 
 	"github.com/lightlink/group-service/internal/group/domain/dto"
 	"github.com/lightlink/group-service/internal/group/domain/entity"
 	"github.com/lightlink/group-service/internal/group/usecase"
 	proto "github.com/lightlink/group-service/protogen/group"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type GroupService struct {
@@ -20,22 +24,169 @@ func NewGroupService(uc usecase.GroupUsecaseI) *GroupService {
 	}
 }
 
+// CreatePersonalGroup is kept for older clients; it is idempotent like GetOrCreatePersonalGroup.
 func (gs *GroupService) CreatePersonalGroup(ctx context.Context, createRequest *proto.CreatePersonalGroupRequest) (*proto.CreatePersonalGroupResponse, error) {
-
-	groupEntity := dto.CreatePersonalGroupRequestToEntity(createRequest)
-	err := gs.groupUC.Create(groupEntity, []entity.GroupMember{
-		{
-			UserID: uint(createRequest.User1Id),
-			Role:   "admin",
-		},
-		{
-			UserID: uint(createRequest.User2Id),
-			Role:   "admin",
-		},
-	})
+	_, _, err := gs.groupUC.GetOrCreatePersonalGroup(uint(createRequest.User1Id), uint(createRequest.User2Id))
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
 	return &proto.CreatePersonalGroupResponse{Status: true}, nil
 }
+
+func (gs *GroupService) GetOrCreatePersonalGroup(ctx context.Context, request *proto.GetOrCreatePersonalGroupRequest) (*proto.GetOrCreatePersonalGroupResponse, error) {
+	if request.User1Id == 0 || request.User2Id == 0 {
+		return nil, status.Error(codes.InvalidArgument, "both user ids are required")
+	}
+
+	groupID, created, err := gs.groupUC.GetOrCreatePersonalGroup(uint(request.User1Id), uint(request.User2Id))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &proto.GetOrCreatePersonalGroupResponse{
+		GroupId: uint32(groupID),
+		Created: created,
+	}, nil
+}
+
+func (gs *GroupService) GetGroup(ctx context.Context, request *proto.GetGroupRequest) (*proto.GetGroupResponse, error) {
+	group, err := gs.groupUC.GetGroup(uint(request.GroupId))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &proto.GetGroupResponse{Group: dto.GroupEntityToProto(*group)}, nil
+}
+
+func (gs *GroupService) ListGroupsForUser(ctx context.Context, request *proto.ListGroupsForUserRequest) (*proto.ListGroupsForUserResponse, error) {
+	if request.UserId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user id is required")
+	}
+
+	groups, err := gs.groupUC.GetConversationsByUserID(uint(request.UserId), request.Type)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	response := &proto.ListGroupsForUserResponse{
+		Groups: make([]*proto.UserGroup, 0, len(groups)),
+	}
+	for _, group := range groups {
+		response.Groups = append(response.Groups, dto.UserGroupEntityToProto(group))
+	}
+
+	return response, nil
+}
+
+func (gs *GroupService) ListMembers(ctx context.Context, request *proto.ListMembersRequest) (*proto.ListMembersResponse, error) {
+	members, err := gs.groupUC.ListMembers(uint(request.GroupId))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	response := &proto.ListMembersResponse{
+		Members: make([]*proto.Member, 0, len(members)),
+	}
+	for _, member := range members {
+		response.Members = append(response.Members, dto.GroupMemberEntityToProto(member))
+	}
+
+	return response, nil
+}
+
+func (gs *GroupService) IsMember(ctx context.Context, request *proto.IsMemberRequest) (*proto.IsMemberResponse, error) {
+	isMember, err := gs.groupUC.IsMember(uint(request.GroupId), uint(request.UserId))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &proto.IsMemberResponse{IsMember: isMember}, nil
+}
+
+func (gs *GroupService) GetPersonalGroupID(ctx context.Context, request *proto.GetPersonalGroupIDRequest) (*proto.GetPersonalGroupIDResponse, error) {
+	if request.User1Id == request.User2Id {
+		return nil, status.Error(codes.InvalidArgument, entity.ErrSelfPersonalGroup.Error())
+	}
+
+	groupID, err := gs.groupUC.GetPersonalGroupID(uint(request.User1Id), uint(request.User2Id))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &proto.GetPersonalGroupIDResponse{GroupId: uint32(groupID)}, nil
+}
+
+func (gs *GroupService) AddMembers(ctx context.Context, request *proto.AddMembersRequest) (*proto.AddMembersResponse, error) {
+	if len(request.Members) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one member is required")
+	}
+
+	err := gs.groupUC.AddMembers(
+		uint(request.InitiatorId),
+		uint(request.GroupId),
+		dto.GroupMembersFromProto(request.Members),
+	)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &proto.AddMembersResponse{}, nil
+}
+
+// SubscribeGroupEvents streams the group's signals until the client goes away. The stream
+// ends with PermissionDenied once the subscriber leaves or is removed from the group.
+func (gs *GroupService) SubscribeGroupEvents(request *proto.SubscribeGroupEventsRequest, stream proto.GroupService_SubscribeGroupEventsServer) error {
+	userID := uint(request.UserId)
+
+	events, unsubscribe, err := gs.groupUC.SubscribeGroupEvents(userID, uint(request.GroupId))
+	if err != nil {
+		return toStatusError(err)
+	}
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "subscriber fell behind, resubscribe")
+			}
+
+			err := stream.Send(&proto.GroupEvent{
+				GroupId:     uint32(event.GroupID),
+				Type:        event.Type,
+				Payload:     event.Payload,
+				PublishedAt: timestamppb.New(event.PublishedAt),
+			})
+			if err != nil {
+				return err
+			}
+
+			if usecase.RevokesMembership(event, userID) {
+				return status.Error(codes.PermissionDenied, entity.ErrNotGroupMember.Error())
+			}
+		}
+	}
+}
+
+func toStatusError(err error) error {
+	switch {
+	case errors.Is(err, entity.ErrGroupNotFound),
+		errors.Is(err, entity.ErrMemberNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, entity.ErrNotGroupMember),
+		errors.Is(err, entity.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, entity.ErrSelfPersonalGroup),
+		errors.Is(err, entity.ErrInvalidRole),
+		errors.Is(err, entity.ErrInvalidGroupType):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, entity.ErrPersonalGroupReadOnly),
+		errors.Is(err, entity.ErrLastAdmin):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
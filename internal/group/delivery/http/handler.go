@@ -2,14 +2,13 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"strconv"
-	"time"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gorilla/mux"
+	"github.com/lightlink/group-service/internal/auth"
 	"github.com/lightlink/group-service/internal/group/domain/dto"
 	"github.com/lightlink/group-service/internal/group/domain/entity"
 	"github.com/lightlink/group-service/internal/group/usecase"
@@ -27,37 +26,25 @@ func NewGroupHandler(groupUsecase usecase.GroupUsecaseI) *GroupHandler {
 
 func (h *GroupHandler) StartCall(w http.ResponseWriter, r *http.Request) {
 	groupIDString := mux.Vars(r)["groupID"]
-	userIDString := r.Header.Get("X-User-ID")
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userIDString := strconv.FormatUint(uint64(userID), 10)
 
 	err := h.groupUC.StartCall(userIDString, groupIDString)
 	if err != nil {
 		fmt.Println("ERR: Error starting call")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeUsecaseError(w, "Failed to start call", err)
 		return
 	}
 }
 
-func generateUserToken(secret, userID, roomID string) (string, error) {
-	claims := jwt.MapClaims{
-		"sub": userID,
-		/*TODO Fix time*/
-		"exp": time.Now().Add(time.Hour * 10).Unix(),
-		"channels": []string{
-			entity.RoomChannel(roomID),
-			entity.UserChannel(roomID, userID),
-			entity.GroupChannel(roomID),
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
-}
-
 func (h *GroupHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
-	userIDString := r.Header.Get("X-User-ID")
-	userID, err := strconv.ParseUint(userIDString, 10, 32)
-	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
@@ -79,14 +66,14 @@ func (h *GroupHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
 
 	groupEntity := &entity.Group{
 		Name:      req.Name,
-		CreatorID: uint(userID),
+		CreatorID: userID,
 		TypeName:  "group",
 	}
 
 	var groupMembers []entity.GroupMember
 
 	groupMembers = append(groupMembers, entity.GroupMember{
-		UserID: uint(userID),
+		UserID: userID,
 		Role:   "admin",
 	})
 
@@ -98,7 +85,7 @@ func (h *GroupHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.groupUC.Create(groupEntity, groupMembers); err != nil {
-		http.Error(w, "Failed to create group: "+err.Error(), http.StatusInternalServerError)
+		writeUsecaseError(w, "Failed to create group", err)
 		return
 	}
 
@@ -110,16 +97,12 @@ func (h *GroupHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *GroupHandler) GetGroups(w http.ResponseWriter, r *http.Request) {
-	userIDString := r.Header.Get("X-User-ID")
-	userID64, err := strconv.ParseUint(userIDString, 10, 32)
-	if err != nil {
-		/*Handle*/
-		fmt.Println(err)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	userID := uint(userID64)
-
 	groups, err := h.groupUC.GetGroupsByUserID(userID)
 	if err != nil {
 		/*Handle*/
@@ -128,13 +111,29 @@ func (h *GroupHandler) GetGroups(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	writeGroups(w, groups)
+}
+
+func (h *GroupHandler) GetConversations(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	groups, err := h.groupUC.GetConversationsByUserID(userID, r.URL.Query().Get("type"))
+	if err != nil {
+		writeUsecaseError(w, "Failed to get conversations", err)
+		return
+	}
+
+	writeGroups(w, groups)
+}
+
+func writeGroups(w http.ResponseWriter, groups []entity.Group) {
 	groupDTOs := []dto.GetGroupResponse{}
 	for _, group := range groups {
-		groupDTO := dto.GetGroupResponse{
-			GroupID:   group.ID,
-			GroupName: group.Name,
-		}
-		groupDTOs = append(groupDTOs, groupDTO)
+		groupDTOs = append(groupDTOs, dto.GroupEntityToResponse(group))
 	}
 
 	response, err := json.Marshal(groupDTOs)
@@ -152,41 +151,66 @@ func (h *GroupHandler) GetGroups(w http.ResponseWriter, r *http.Request) {
 
 func (h *GroupHandler) InfoHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("Handling incoming info request")
-	userIDString := r.Header.Get("X-User-ID")
-	groupID := mux.Vars(r)["groupID"]
+	h.writeGroupTokens(w, r)
+}
+
+// RefreshGroupTokens renews the connection token of a group member.
+func (h *GroupHandler) RefreshGroupTokens(w http.ResponseWriter, r *http.Request) {
+	h.writeGroupTokens(w, r)
+}
+
+func (h *GroupHandler) RefreshConnectionToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
-	token, err := generateUserToken(os.Getenv("TOKEN_KEY"), userIDString, groupID)
+	token, err := h.groupUC.IssueConnectionToken(userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeUsecaseError(w, "Failed to issue token", err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"token": token,
-		"channels": map[string]string{
-			"room":           entity.RoomChannel(groupID),
-			"group_messages": entity.GroupChannel(groupID),
-			"user":           entity.UserChannel(groupID, userIDString),
-		},
+	json.NewEncoder(w).Encode(dto.ConnectionTokenResponse{
+		Token:     token.Token,
+		ExpiresAt: token.ExpiresAt,
 	})
 }
 
-func (h *GroupHandler) GetPersonalGroupID(w http.ResponseWriter, r *http.Request) {
-	userIDString := r.Header.Get("X-User-ID")
-	userID64, err := strconv.ParseUint(userIDString, 10, 32)
+func (h *GroupHandler) writeGroupTokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	groupID, err := parseID(mux.Vars(r)["groupID"])
 	if err != nil {
-		/*Handle*/
-		fmt.Println(err)
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.groupUC.IssueGroupTokens(userID, groupID)
+	if err != nil {
+		writeUsecaseError(w, "Failed to issue tokens", err)
 		return
 	}
 
-	userID := uint(userID64)
+	json.NewEncoder(w).Encode(dto.GroupTokensEntityToResponse(*tokens))
+}
+
+func (h *GroupHandler) GetPersonalGroupID(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
 	friendIDString := mux.Vars(r)["friendID"]
 	friendID64, err := strconv.ParseUint(friendIDString, 10, 32)
 	if err != nil {
-		/*Handle*/
-		fmt.Println(err)
+		http.Error(w, "Invalid friend ID", http.StatusBadRequest)
 		return
 	}
 
@@ -194,8 +218,7 @@ func (h *GroupHandler) GetPersonalGroupID(w http.ResponseWriter, r *http.Request
 
 	groupID, err := h.groupUC.GetPersonalGroupID(userID, friendID)
 	if err != nil {
-		/*Handle*/
-		fmt.Println(err)
+		writeUsecaseError(w, "Failed to get personal group", err)
 		return
 	}
 
@@ -216,6 +239,257 @@ func (h *GroupHandler) GetPersonalGroupID(w http.ResponseWriter, r *http.Request
 	}
 }
 
+func (h *GroupHandler) AddMembers(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	groupID, err := parseID(mux.Vars(r)["groupID"])
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.AddMembersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Members) == 0 {
+		http.Error(w, "At least one member is required", http.StatusBadRequest)
+		return
+	}
+
+	groupMembers := make([]entity.GroupMember, 0, len(req.Members))
+	for _, m := range req.Members {
+		groupMembers = append(groupMembers, entity.GroupMember{
+			UserID: m.UserID,
+			Role:   m.Role,
+		})
+	}
+
+	if err := h.groupUC.AddMembers(userID, groupID, groupMembers); err != nil {
+		writeUsecaseError(w, "Failed to add members", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *GroupHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	groupID, err := parseID(mux.Vars(r)["groupID"])
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.RemoveMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.groupUC.RemoveMember(userID, groupID, req.UserID); err != nil {
+		writeUsecaseError(w, "Failed to remove member", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *GroupHandler) LeaveGroup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	groupID, err := parseID(mux.Vars(r)["groupID"])
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.groupUC.LeaveGroup(userID, groupID); err != nil {
+		writeUsecaseError(w, "Failed to leave group", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *GroupHandler) ChangeMemberRole(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	groupID, err := parseID(mux.Vars(r)["groupID"])
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	memberID, err := parseID(mux.Vars(r)["userID"])
+	if err != nil {
+		http.Error(w, "Invalid member ID", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.ChangeMemberRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.groupUC.ChangeMemberRole(userID, groupID, memberID, req.Role); err != nil {
+		writeUsecaseError(w, "Failed to change member role", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *GroupHandler) RenameGroup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	groupID, err := parseID(mux.Vars(r)["groupID"])
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.RenameGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.groupUC.Rename(userID, groupID, req.Name); err != nil {
+		writeUsecaseError(w, "Failed to rename group", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *GroupHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	groupID, err := parseID(mux.Vars(r)["groupID"])
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.MarkReadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.MessageID == 0 {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.groupUC.MarkRead(userID, groupID, req.MessageID); err != nil {
+		writeUsecaseError(w, "Failed to mark group as read", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *GroupHandler) Typing(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	groupID, err := parseID(mux.Vars(r)["groupID"])
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.groupUC.StartTyping(userID, groupID); err != nil {
+		writeUsecaseError(w, "Failed to send typing signal", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *GroupHandler) GetPresence(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	groupID, err := parseID(mux.Vars(r)["groupID"])
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	members, err := h.groupUC.GetPresence(userID, groupID)
+	if err != nil {
+		writeUsecaseError(w, "Failed to get presence", err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(dto.GroupPresenceEntityToResponse(members))
+}
+
+func parseID(idString string) (uint, error) {
+	id, err := strconv.ParseUint(idString, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint(id), nil
+}
+
+func writeUsecaseError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, entity.ErrGroupNotFound),
+		errors.Is(err, entity.ErrMemberNotFound):
+		http.Error(w, message+": "+err.Error(), http.StatusNotFound)
+	case errors.Is(err, entity.ErrNotGroupMember),
+		errors.Is(err, entity.ErrPermissionDenied):
+		http.Error(w, message+": "+err.Error(), http.StatusForbidden)
+	case errors.Is(err, entity.ErrInvalidRole),
+		errors.Is(err, entity.ErrInvalidGroupName),
+		errors.Is(err, entity.ErrMessageNotInGroup),
+		errors.Is(err, entity.ErrInvalidGroupType):
+		http.Error(w, message+": "+err.Error(), http.StatusBadRequest)
+	case errors.Is(err, entity.ErrPersonalGroupReadOnly),
+		errors.Is(err, entity.ErrLastAdmin):
+		http.Error(w, message+": "+err.Error(), http.StatusConflict)
+	case errors.Is(err, entity.ErrRateLimited):
+		http.Error(w, message+": "+err.Error(), http.StatusTooManyRequests)
+	default:
+		fmt.Println(message+":", err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
 // func (h *GroupHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
 // 	body, err := io.ReadAll(r.Body)
 // 	if err != nil {
@@ -246,7 +520,7 @@ func (h *GroupHandler) GetPersonalGroupID(w http.ResponseWriter, r *http.Request
 // 		return
 // 	}
 
-// 	userID := uint(userID64)
+// 	userID := userID
 
 // 	createGroupRequest.UserID = userID
 
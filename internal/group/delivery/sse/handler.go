@@ -0,0 +1,264 @@
+package sse
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lightlink/group-service/infrastructure/ws"
+	"github.com/lightlink/group-service/internal/auth"
+	groupEntity "github.com/lightlink/group-service/internal/group/domain/entity"
+	groupUsecase "github.com/lightlink/group-service/internal/group/usecase"
+	messageDTO "github.com/lightlink/group-service/internal/message/domain/dto"
+	messageUsecase "github.com/lightlink/group-service/internal/message/usecase"
+)
+
+const (
+	HEARTBEAT_INTERVAL  = 25 * time.Second
+	REPLAY_PAGE_LIMIT   = 100
+	MAX_REPLAY_MESSAGES = 1000
+
+	NO_EVENT_ID = 0
+)
+
+// EventStreamHandler serves group signals as Server-Sent Events for clients that cannot use WebSockets.
+// Only message changes carry an id: their change_seq, which follows commit order within a group
+// (message ids do not), so Last-Event-ID resumes from the group's change stream. Ids never go
+// backwards: messages are always sent as read by Sync, in change_seq order.
+type EventStreamHandler struct {
+	groupUC      groupUsecase.GroupUsecaseI
+	messageUC    messageUsecase.MessageUsecaseI
+	eventStreams ws.GroupEventSubscriber
+}
+
+func NewEventStreamHandler(
+	groupUC groupUsecase.GroupUsecaseI,
+	messageUC messageUsecase.MessageUsecaseI,
+	eventStreams ws.GroupEventSubscriber,
+) *EventStreamHandler {
+	return &EventStreamHandler{
+		groupUC:      groupUC,
+		messageUC:    messageUC,
+		eventStreams: eventStreams,
+	}
+}
+
+func (h *EventStreamHandler) StreamGroupEvents(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	groupID64, err := strconv.ParseUint(mux.Vars(r)["groupID"], 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+	groupID := uint(groupID64)
+
+	lastEventID, err := parseLastEventID(r)
+	if err != nil {
+		http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	isMember, err := h.groupUC.IsMember(groupID, userID)
+	if err != nil {
+		fmt.Println("Failed to check group membership:", err)
+		http.Error(w, "Failed to subscribe to group events", http.StatusInternalServerError)
+		return
+	}
+	if !isMember {
+		http.Error(w, groupEntity.ErrNotGroupMember.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Subscribe before replaying, so nothing published in between is lost.
+	events, unsubscribe := h.eventStreams.SubscribeGroup(groupID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// sentChangeSeq is the last id written: every change up to it has been sent.
+	sentChangeSeq := lastEventID
+	if lastEventID != 0 {
+		sentChangeSeq, err = h.replayChanges(w, userID, groupID, lastEventID)
+		if err != nil {
+			fmt.Println("Failed to replay group messages:", err)
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(HEARTBEAT_INTERVAL)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-events:
+			if !ok {
+				// Dropped as a slow subscriber; the client reconnects with Last-Event-ID.
+				return
+			}
+
+			changeSeq := newMessageChangeSeq(event)
+			switch {
+			case changeSeq == 0:
+				if err := writeEvent(w, NO_EVENT_ID, eventSignal(event)); err != nil {
+					return
+				}
+			case changeSeq > sentChangeSeq:
+				// The relay may deliver messages out of commit order, so the live event only
+				// triggers a read of everything committed since the last id, in change_seq order.
+				sentChangeSeq, err = h.replayChanges(w, userID, groupID, sentChangeSeq)
+				if err != nil {
+					fmt.Println("Failed to read group messages:", err)
+					return
+				}
+			default:
+				// Already sent by an earlier read.
+				continue
+			}
+			flusher.Flush()
+
+			if groupUsecase.RevokesMembership(event, userID) {
+				return
+			}
+		}
+	}
+}
+
+// replayChanges sends the group's message changes after afterChangeSeq and returns the
+// change_seq it stopped at. Existing messages are sent as newMessage with their current state,
+// so clients upsert them by id; deleted ones as messageDeleted. Past MAX_REPLAY_MESSAGES the
+// client is told to reload the history.
+func (h *EventStreamHandler) replayChanges(w http.ResponseWriter, userID, groupID uint, afterChangeSeq int64) (int64, error) {
+	replayed := 0
+	for {
+		changes, err := h.messageUC.Sync(userID, groupID, afterChangeSeq, REPLAY_PAGE_LIMIT)
+		if err != nil {
+			return afterChangeSeq, err
+		}
+
+		for _, messageID := range changes.DeletedMessageIDs {
+			err := writeEvent(w, NO_EVENT_ID, messageDTO.MessageSignal{
+				Type: "messageDeleted",
+				Payload: messageDTO.MessageDeletedPayload{
+					MessageID: messageID,
+					GroupID:   groupID,
+				},
+			})
+			if err != nil {
+				return afterChangeSeq, err
+			}
+		}
+
+		for _, message := range changes.Messages {
+			err := writeEvent(w, message.ChangeSeq, messageDTO.MessageSignal{
+				Type:    "newMessage",
+				Payload: messageDTO.MessageEntityToIncomingPayload(message),
+			})
+			if err != nil {
+				return afterChangeSeq, err
+			}
+		}
+
+		afterChangeSeq = changes.Cursor
+		replayed += len(changes.Messages) + len(changes.DeletedMessageIDs)
+
+		if !changes.HasMore {
+			return afterChangeSeq, nil
+		}
+
+		if replayed >= MAX_REPLAY_MESSAGES {
+			return afterChangeSeq, writeEvent(w, NO_EVENT_ID, messageDTO.MessageSignal{Type: "resync"})
+		}
+	}
+}
+
+// writeEvent writes no id: line for NO_EVENT_ID, so the browser keeps its last event id.
+func writeEvent(w http.ResponseWriter, eventID int64, signal interface{}) error {
+	data, err := json.Marshal(signal)
+	if err != nil {
+		return err
+	}
+
+	if eventID != NO_EVENT_ID {
+		if _, err := fmt.Fprintf(w, "id: %d\n", eventID); err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+	return err
+}
+
+// eventSignal restores the {"type", "payload"} shape published to Centrifugo.
+func eventSignal(event ws.Event) interface{} {
+	if event.Type == "" {
+		return event.Payload
+	}
+
+	return struct {
+		Type    string          `json:"type"`
+		Payload json.RawMessage `json:"payload"`
+	}{
+		Type:    event.Type,
+		Payload: event.Payload,
+	}
+}
+
+func newMessageChangeSeq(event ws.Event) int64 {
+	if event.Type != "newMessage" {
+		return 0
+	}
+
+	var payload struct {
+		ChangeSeq int64 `json:"change_seq"`
+	}
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return 0
+	}
+
+	return payload.ChangeSeq
+}
+
+// parseLastEventID also accepts a last_event_id query parameter, since EventSource
+// cannot set headers on the first connection.
+func parseLastEventID(r *http.Request) (int64, error) {
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	if lastEventID == "" {
+		return 0, nil
+	}
+
+	changeSeq, err := strconv.ParseInt(lastEventID, 10, 64)
+	if err != nil || changeSeq < 0 {
+		return 0, fmt.Errorf("invalid event id %q", lastEventID)
+	}
+
+	return changeSeq, nil
+}
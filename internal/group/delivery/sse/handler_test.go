@@ -0,0 +1,73 @@
+package sse
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lightlink/group-service/infrastructure/ws"
+)
+
+func TestParseLastEventID(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		query   string
+		want    int64
+		wantErr bool
+	}{
+		{name: "first connection", want: 0},
+		{name: "header", header: "42", want: 42},
+		{name: "query fallback", query: "17", want: 17},
+		{name: "header wins over query", header: "42", query: "17", want: 42},
+		{name: "zero", header: "0", want: 0},
+		{name: "non-numeric", header: "abc", wantErr: true},
+		{name: "negative", header: "-1", wantErr: true},
+		{name: "overflow", header: "9223372036854775808", wantErr: true},
+		{name: "non-numeric query", query: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/group/1/events", nil)
+			if tt.header != "" {
+				r.Header.Set("Last-Event-ID", tt.header)
+			}
+			if tt.query != "" {
+				r.URL.RawQuery = "last_event_id=" + tt.query
+			}
+
+			got, err := parseLastEventID(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLastEventID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseLastEventID() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewMessageChangeSeq(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventType string
+		payload   string
+		want      int64
+	}{
+		{name: "new message", eventType: "newMessage", payload: `{"id":5,"change_seq":12}`, want: 12},
+		{name: "new message without change_seq", eventType: "newMessage", payload: `{"id":5}`, want: NO_EVENT_ID},
+		{name: "malformed payload", eventType: "newMessage", payload: `"12"`, want: NO_EVENT_ID},
+		{name: "other signal with change_seq", eventType: "updateMessage", payload: `{"change_seq":12}`, want: NO_EVENT_ID},
+		{name: "typing", eventType: "typing", payload: `{"user_id":1}`, want: NO_EVENT_ID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := ws.Event{GroupID: 1, Type: tt.eventType, Payload: json.RawMessage(tt.payload)}
+			if got := newMessageChangeSeq(event); got != tt.want {
+				t.Errorf("newMessageChangeSeq() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,10 +1,9 @@
 package dto
 
 import (
-	"fmt"
+	"time"
 
 	"github.com/lightlink/group-service/internal/group/domain/entity"
-	proto "github.com/lightlink/group-service/protogen/group"
 )
 
 type GetPersonalGroupIDResponse struct {
@@ -12,8 +11,28 @@ type GetPersonalGroupIDResponse struct {
 }
 
 type GetGroupResponse struct {
-	GroupID   uint   `json:"group_id"`
-	GroupName string `json:"name"`
+	GroupID        uint                 `json:"group_id"`
+	GroupName      string               `json:"name"`
+	Type           string               `json:"type"`
+	CreatorID      uint                 `json:"creator_id"`
+	Role           string               `json:"role"`
+	MemberCount    uint                 `json:"member_count"`
+	UnreadCount    uint                 `json:"unread_count"`
+	LastMessage    *LastMessageResponse `json:"last_message"`
+	LastActivityAt time.Time            `json:"last_activity_at"`
+	PeerUserID     *uint                `json:"peer_user_id,omitempty"`
+}
+
+type LastMessageResponse struct {
+	ID        uint      `json:"id"`
+	UserID    uint      `json:"user_id"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	IsDeleted bool      `json:"is_deleted"`
+}
+
+type MarkReadRequest struct {
+	MessageID uint `json:"message_id"`
 }
 
 type CreateGroupRequest struct {
@@ -26,10 +45,95 @@ type GroupMemberDTO struct {
 	Role   string `json:"role"`
 }
 
-func CreatePersonalGroupRequestToEntity(createRequest *proto.CreatePersonalGroupRequest) *entity.Group {
-	return &entity.Group{
-		Name:      fmt.Sprintf("personal-%d-%d", createRequest.User1Id, createRequest.User2Id),
-		CreatorID: uint(createRequest.User1Id),
-		TypeName:  "personal",
+type AddMembersRequest struct {
+	Members []GroupMemberDTO `json:"members"`
+}
+
+type RemoveMemberRequest struct {
+	UserID uint `json:"user_id"`
+}
+
+type ChangeMemberRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type RenameGroupRequest struct {
+	Name string `json:"name"`
+}
+
+func GroupEntityToResponse(group entity.Group) GetGroupResponse {
+	response := GetGroupResponse{
+		GroupID:        group.ID,
+		GroupName:      group.Name,
+		Type:           group.TypeName,
+		CreatorID:      group.CreatorID,
+		Role:           group.Role,
+		MemberCount:    group.MemberCount,
+		UnreadCount:    group.UnreadCount,
+		LastActivityAt: group.LastActivityAt,
+		PeerUserID:     group.PeerUserID,
+	}
+
+	if group.LastMessage != nil {
+		response.LastMessage = &LastMessageResponse{
+			ID:        group.LastMessage.ID,
+			UserID:    group.LastMessage.UserID,
+			Content:   group.LastMessage.Content,
+			CreatedAt: group.LastMessage.CreatedAt,
+			IsDeleted: group.LastMessage.IsDeleted,
+		}
+	}
+
+	return response
+}
+
+type ConnectionTokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type GroupTokensResponse struct {
+	Token     string            `json:"token"`
+	ExpiresAt time.Time         `json:"expires_at"`
+	Channels  map[string]string `json:"channels"`
+}
+
+func GroupTokensEntityToResponse(tokens entity.GroupTokens) GroupTokensResponse {
+	return GroupTokensResponse{
+		Token:     tokens.Token,
+		ExpiresAt: tokens.ExpiresAt,
+		Channels:  tokens.Channels,
 	}
 }
+
+type MemberPresenceResponse struct {
+	UserID     uint       `json:"user_id"`
+	Online     bool       `json:"online"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+}
+
+type GroupPresenceResponse struct {
+	OnlineUserIDs []uint                   `json:"online_user_ids"`
+	Members       []MemberPresenceResponse `json:"members"`
+}
+
+func GroupPresenceEntityToResponse(members []entity.MemberPresence) GroupPresenceResponse {
+	response := GroupPresenceResponse{
+		OnlineUserIDs: make([]uint, 0, len(members)),
+		Members:       make([]MemberPresenceResponse, 0, len(members)),
+	}
+
+	for _, member := range members {
+		if member.Online {
+			response.OnlineUserIDs = append(response.OnlineUserIDs, member.UserID)
+		}
+
+		response.Members = append(response.Members, MemberPresenceResponse{
+			UserID:     member.UserID,
+			Online:     member.Online,
+			LastSeenAt: member.LastSeenAt,
+		})
+	}
+
+	return response
+}
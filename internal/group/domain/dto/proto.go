@@ -0,0 +1,52 @@
+package dto
+
+import (
+	"github.com/lightlink/group-service/internal/group/domain/entity"
+	proto "github.com/lightlink/group-service/protogen/group"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func GroupEntityToProto(group entity.Group) *proto.Group {
+	return &proto.Group{
+		Id:          uint32(group.ID),
+		Name:        group.Name,
+		CreatorId:   uint32(group.CreatorID),
+		Type:        group.TypeName,
+		MemberCount: uint32(group.MemberCount),
+		CreatedAt:   timestamppb.New(group.CreatedAt),
+	}
+}
+
+func UserGroupEntityToProto(group entity.Group) *proto.UserGroup {
+	userGroup := &proto.UserGroup{
+		Group:          GroupEntityToProto(group),
+		Role:           group.Role,
+		UnreadCount:    uint32(group.UnreadCount),
+		LastActivityAt: timestamppb.New(group.LastActivityAt),
+	}
+
+	if group.PeerUserID != nil {
+		userGroup.PeerUserId = uint32(*group.PeerUserID)
+	}
+
+	return userGroup
+}
+
+func GroupMemberEntityToProto(member entity.GroupMember) *proto.Member {
+	return &proto.Member{
+		UserId: uint32(member.UserID),
+		Role:   member.Role,
+	}
+}
+
+func GroupMembersFromProto(members []*proto.Member) []entity.GroupMember {
+	groupMembers := make([]entity.GroupMember, 0, len(members))
+	for _, member := range members {
+		groupMembers = append(groupMembers, entity.GroupMember{
+			UserID: uint(member.UserId),
+			Role:   member.Role,
+		})
+	}
+
+	return groupMembers
+}
@@ -0,0 +1,53 @@
+package dto
+
+import "time"
+
+type GroupSignal struct {
+	Type    string      `json:"type"`
+	Payload interface{} `json:"payload"`
+}
+
+type MembersAddedPayload struct {
+	GroupID   uint   `json:"group_id"`
+	AddedByID uint   `json:"added_by_id"`
+	UserIDs   []uint `json:"user_ids"`
+}
+
+type MemberRemovedPayload struct {
+	GroupID     uint `json:"group_id"`
+	RemovedByID uint `json:"removed_by_id"`
+	UserID      uint `json:"user_id"`
+}
+
+type MemberRoleChangedPayload struct {
+	GroupID     uint   `json:"group_id"`
+	ChangedByID uint   `json:"changed_by_id"`
+	UserID      uint   `json:"user_id"`
+	Role        string `json:"role"`
+}
+
+type GroupRenamedPayload struct {
+	GroupID     uint   `json:"group_id"`
+	RenamedByID uint   `json:"renamed_by_id"`
+	Name        string `json:"name"`
+}
+
+type ReadReceiptPayload struct {
+	GroupID           uint `json:"group_id"`
+	UserID            uint `json:"user_id"`
+	LastReadMessageID uint `json:"last_read_message_id"`
+}
+
+// TypingPayload is ephemeral: clients stop showing the indicator after ExpiresAt
+// unless another typing signal arrives.
+type TypingPayload struct {
+	GroupID   uint      `json:"group_id"`
+	UserID    uint      `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// IncomingCallPayload is sent to the user channel of every member the call rings for.
+type IncomingCallPayload struct {
+	GroupID    uint `json:"group_id"`
+	FromUserID uint `json:"from_user_id"`
+}
@@ -1,6 +1,9 @@
 package entity
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 func UserChannel(groupID, userID string) string {
 	return fmt.Sprintf("group:%s:user:%s", groupID, userID)
@@ -13,3 +16,18 @@ func RoomChannel(roomID string) string {
 func GroupChannel(groupID string) string {
 	return fmt.Sprintf("group_messages:%s", groupID)
 }
+
+// ParseChannel reverses RoomChannel, GroupChannel and UserChannel.
+// userID is only set for user channels.
+func ParseChannel(channel string) (groupID, userID string, ok bool) {
+	parts := strings.Split(channel, ":")
+
+	switch {
+	case len(parts) == 2 && (parts[0] == "room" || parts[0] == "group_messages"):
+		return parts[1], "", parts[1] != ""
+	case len(parts) == 4 && parts[0] == "group" && parts[2] == "user":
+		return parts[1], parts[3], parts[1] != "" && parts[3] != ""
+	default:
+		return "", "", false
+	}
+}
@@ -0,0 +1,37 @@
+package entity
+
+import "testing"
+
+func TestParseChannel(t *testing.T) {
+	tests := []struct {
+		name        string
+		channel     string
+		wantGroupID string
+		wantUserID  string
+		wantOK      bool
+	}{
+		{name: "room channel", channel: RoomChannel("7"), wantGroupID: "7", wantOK: true},
+		{name: "group channel", channel: GroupChannel("7"), wantGroupID: "7", wantOK: true},
+		{name: "user channel", channel: UserChannel("7", "42"), wantGroupID: "7", wantUserID: "42", wantOK: true},
+		{name: "empty group id", channel: "group_messages:", wantOK: false},
+		{name: "empty user id", channel: "group:7:user:", wantOK: false},
+		{name: "unknown namespace", channel: "personal:7", wantOK: false},
+		{name: "extra segment", channel: "room:7:8", wantOK: false},
+		{name: "malformed user channel", channel: "group:7:member:42", wantOK: false},
+		{name: "no namespace", channel: "7", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groupID, userID, ok := ParseChannel(tt.channel)
+			if ok != tt.wantOK {
+				t.Fatalf("ParseChannel(%q) ok = %v, want %v", tt.channel, ok, tt.wantOK)
+			}
+			// The ids are meaningless when the channel is rejected.
+			if ok && (groupID != tt.wantGroupID || userID != tt.wantUserID) {
+				t.Errorf("ParseChannel(%q) = (%q, %q), want (%q, %q)",
+					tt.channel, groupID, userID, tt.wantGroupID, tt.wantUserID)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package entity
+
+import "errors"
+
+var (
+	ErrGroupNotFound         = errors.New("group not found")
+	ErrNotGroupMember        = errors.New("user is not a member of the group")
+	ErrPermissionDenied      = errors.New("permission denied")
+	ErrPersonalGroupReadOnly = errors.New("personal group cannot be modified")
+	ErrInvalidRole           = errors.New("unknown role")
+	ErrInvalidGroupName      = errors.New("group name is required")
+	ErrMessageNotInGroup     = errors.New("message does not belong to the group")
+	ErrInvalidGroupType      = errors.New("unknown group type")
+	ErrSelfPersonalGroup     = errors.New("personal group needs two different users")
+	ErrRateLimited           = errors.New("too many requests")
+	ErrMemberNotFound        = errors.New("target user is not a member of the group")
+	ErrLastAdmin             = errors.New("the last admin cannot leave the group")
+)
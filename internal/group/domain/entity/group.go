@@ -1,8 +1,28 @@
 package entity
 
+import "time"
+
 type Group struct {
 	ID        uint
 	Name      string
 	CreatorID uint
 	TypeName  string
+	CreatedAt time.Time
+
+	Role           string
+	MemberCount    uint
+	UnreadCount    uint
+	LastMessage    *LastMessage
+	LastActivityAt time.Time
+
+	// PeerUserID is the other participant of a personal group.
+	PeerUserID *uint
+}
+
+type LastMessage struct {
+	ID        uint
+	UserID    uint
+	Content   string
+	CreatedAt time.Time
+	IsDeleted bool
 }
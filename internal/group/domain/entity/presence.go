@@ -0,0 +1,10 @@
+package entity
+
+import "time"
+
+// MemberPresence has a nil LastSeenAt for members that have never connected.
+type MemberPresence struct {
+	UserID     uint
+	Online     bool
+	LastSeenAt *time.Time
+}
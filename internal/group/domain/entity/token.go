@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+type ConnectionToken struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// GroupTokens lets a member connect and names the group's realtime channels.
+// Channels maps a channel kind ("room", "group_messages", "user") to the channel name.
+// There are no subscription tokens: Centrifugo skips the subscribe proxy for them, so a
+// removed member could resubscribe until the token expired.
+type GroupTokens struct {
+	ConnectionToken
+	Channels map[string]string
+}
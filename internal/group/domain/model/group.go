@@ -1,8 +1,28 @@
 package model
 
+import "time"
+
 type Group struct {
-	ID        uint   `db:"id"`
-	Name      string `db:"name"`
-	CreatorID uint   `db:"creator_id"`
-	TypeID    uint   `db:"type_id"`
+	ID        uint      `db:"id"`
+	Name      string    `db:"name"`
+	CreatorID uint      `db:"creator_id"`
+	TypeID    uint      `db:"type_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// GroupSummary is a group as listed for one of its members.
+type GroupSummary struct {
+	Group
+	TypeName       string    `db:"type_name"`
+	Role           string    `db:"role"`
+	MemberCount    uint      `db:"member_count"`
+	UnreadCount    uint      `db:"unread_count"`
+	LastActivityAt time.Time `db:"last_activity_at"`
+	PeerUserID     *uint     `db:"peer_user_id"`
+
+	LastMessageID        *uint      `db:"last_message_id"`
+	LastMessageUserID    *uint      `db:"last_message_user_id"`
+	LastMessageContent   *string    `db:"last_message_content"`
+	LastMessageCreatedAt *time.Time `db:"last_message_created_at"`
+	LastMessageDeleted   bool       `db:"last_message_deleted"`
 }
@@ -4,4 +4,6 @@ type GroupMember struct {
 	UserID  uint `db:"user_id"`
 	GroupID uint `db:"group_id"`
 	RoleID  uint `db:"role_id"`
+
+	LastReadMessageID *uint `db:"last_read_message_id"`
 }
@@ -0,0 +1,93 @@
+package permission
+
+import (
+	"github.com/lightlink/group-service/internal/group/domain/entity"
+	"github.com/lightlink/group-service/internal/group/repository"
+)
+
+type Action string
+
+const (
+	SendMessage    Action = "send_message"
+	AttachFiles    Action = "attach_files"
+	StartCall      Action = "start_call"
+	ManageMembers  Action = "manage_members"
+	ManageRoles    Action = "manage_roles"
+	RenameGroup    Action = "rename_group"
+	DeleteMessages Action = "delete_messages"
+)
+
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+	RoleMember    = "member"
+	RoleReader    = "reader"
+)
+
+var rolePermissions = map[string]map[Action]bool{
+	RoleAdmin: {
+		SendMessage:    true,
+		AttachFiles:    true,
+		StartCall:      true,
+		ManageMembers:  true,
+		ManageRoles:    true,
+		RenameGroup:    true,
+		DeleteMessages: true,
+	},
+	RoleModerator: {
+		SendMessage:    true,
+		AttachFiles:    true,
+		StartCall:      true,
+		ManageMembers:  true,
+		DeleteMessages: true,
+	},
+	RoleMember: {
+		SendMessage: true,
+		AttachFiles: true,
+		StartCall:   true,
+	},
+	RoleReader: {},
+}
+
+func IsKnownRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+func Allowed(role string, action Action) bool {
+	return rolePermissions[role][action]
+}
+
+// Checker resolves the caller's role in a group and verifies it grants the requested action.
+type Checker struct {
+	groupRepo repository.GroupRepositoryI
+}
+
+func NewChecker(groupRepo repository.GroupRepositoryI) *Checker {
+	return &Checker{
+		groupRepo: groupRepo,
+	}
+}
+
+// RequireMember fails with entity.ErrGroupNotFound for a missing group and
+// entity.ErrNotGroupMember for non-members.
+func (c *Checker) RequireMember(groupID, userID uint) error {
+	_, err := c.groupRepo.GetMemberRole(groupID, userID)
+	return err
+}
+
+// Check fails like RequireMember for non-members, so it doubles as a membership check.
+func (c *Checker) Check(groupID, userID uint, actions ...Action) error {
+	role, err := c.groupRepo.GetMemberRole(groupID, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, action := range actions {
+		if !Allowed(role, action) {
+			return entity.ErrPermissionDenied
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package permission
+
+import "testing"
+
+var allActions = []Action{
+	SendMessage, AttachFiles, StartCall, ManageMembers, ManageRoles, RenameGroup, DeleteMessages,
+}
+
+func TestAllowed(t *testing.T) {
+	tests := []struct {
+		name        string
+		role        string
+		wantKnown   bool
+		wantAllowed []Action
+	}{
+		{name: "admin", role: RoleAdmin, wantKnown: true, wantAllowed: allActions},
+		{
+			name:        "moderator",
+			role:        RoleModerator,
+			wantKnown:   true,
+			wantAllowed: []Action{SendMessage, AttachFiles, StartCall, ManageMembers, DeleteMessages},
+		},
+		{name: "member", role: RoleMember, wantKnown: true, wantAllowed: []Action{SendMessage, AttachFiles, StartCall}},
+		{name: "reader", role: RoleReader, wantKnown: true},
+		{name: "unknown role", role: "owner", wantKnown: false},
+		{name: "empty role", role: "", wantKnown: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsKnownRole(tt.role); got != tt.wantKnown {
+				t.Errorf("IsKnownRole(%q) = %v, want %v", tt.role, got, tt.wantKnown)
+			}
+
+			want := make(map[Action]bool, len(tt.wantAllowed))
+			for _, action := range tt.wantAllowed {
+				want[action] = true
+			}
+
+			// Every action is checked, so a grant added to the map without a test fails too.
+			for _, action := range allActions {
+				if got := Allowed(tt.role, action); got != want[action] {
+					t.Errorf("Allowed(%q, %q) = %v, want %v", tt.role, action, got, want[action])
+				}
+			}
+			if Allowed(tt.role, Action("unknown_action")) {
+				t.Errorf("Allowed(%q, unknown_action) = true, want false", tt.role)
+			}
+		})
+	}
+}
@@ -2,12 +2,16 @@ package postgres
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/lightlink/group-service/internal/group/domain/entity"
 	"github.com/lightlink/group-service/internal/group/domain/model"
 )
 
+// Длина превью последнего сообщения в списке групп
+const lastMessagePreviewLength = 100
+
 type GroupPostgresRepository struct {
 	DB *sql.DB
 }
@@ -22,16 +26,14 @@ func (repo *GroupPostgresRepository) GetPersonalGroupID(user1ID uint, user2ID ui
 	var groupID uint
 
 	err := repo.DB.QueryRow(
-		`SELECT g.id
-		FROM groups g
-		JOIN group_types gt ON g.type_id = gt.id
-		JOIN group_members gm1 ON g.id = gm1.group_id
-		JOIN group_members gm2 ON g.id = gm2.group_id
-		WHERE gt.name = 'personal' 
-			AND gm1.user_id = $1 
-			AND gm2.user_id = $2
-		LIMIT 1;`,
+		`SELECT group_id
+		FROM personal_groups
+		WHERE user_low_id = LEAST($1::INTEGER, $2::INTEGER)
+			AND user_high_id = GREATEST($1::INTEGER, $2::INTEGER)`,
 		user1ID, user2ID).Scan(&groupID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, entity.ErrGroupNotFound
+	}
 	if err != nil {
 		return 0, err
 	}
@@ -39,6 +41,71 @@ func (repo *GroupPostgresRepository) GetPersonalGroupID(user1ID uint, user2ID ui
 	return groupID, nil
 }
 
+func (repo *GroupPostgresRepository) GetByID(groupID uint) (*model.GroupSummary, error) {
+	group := &model.GroupSummary{}
+
+	err := repo.DB.QueryRow(
+		`SELECT
+			g.id,
+			g.name,
+			g.creator_id,
+			g.type_id,
+			g.created_at,
+			gt.name,
+			(SELECT COUNT(*) FROM group_members c WHERE c.group_id = g.id) AS member_count
+		FROM groups g
+		JOIN group_types gt ON g.type_id = gt.id
+		WHERE g.id = $1`,
+		groupID,
+	).Scan(
+		&group.ID,
+		&group.Name,
+		&group.CreatorID,
+		&group.TypeID,
+		&group.CreatedAt,
+		&group.TypeName,
+		&group.MemberCount,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, entity.ErrGroupNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query group: %w", err)
+	}
+
+	return group, nil
+}
+
+func (repo *GroupPostgresRepository) GetMembers(groupID uint) ([]entity.GroupMember, error) {
+	rows, err := repo.DB.Query(
+		`SELECT gm.user_id, r.name
+		FROM group_members gm
+		JOIN roles r ON gm.role_id = r.id
+		WHERE gm.group_id = $1
+		ORDER BY gm.user_id`,
+		groupID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query group members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []entity.GroupMember
+	for rows.Next() {
+		var member entity.GroupMember
+		if err := rows.Scan(&member.UserID, &member.Role); err != nil {
+			return nil, fmt.Errorf("failed to scan group member: %w", err)
+		}
+		members = append(members, member)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return members, nil
+}
+
 func (repo *GroupPostgresRepository) GetMemberIDsByGroupID(groupID uint) ([]uint, error) {
 	var memberIDs []uint
 
@@ -66,35 +133,296 @@ func (repo *GroupPostgresRepository) GetMemberIDsByGroupID(groupID uint) ([]uint
 	return memberIDs, nil
 }
 
-func (repo *GroupPostgresRepository) GetGroupsByUserID(userID uint) ([]model.Group, error) {
+func (repo *GroupPostgresRepository) IsMember(groupID uint, userID uint) (bool, error) {
+	var isMember bool
+
+	err := repo.DB.QueryRow(
+		`SELECT EXISTS (
+			SELECT 1 FROM group_members WHERE group_id = $1 AND user_id = $2
+		)`,
+		groupID, userID,
+	).Scan(&isMember)
+	if err != nil {
+		return false, fmt.Errorf("failed to check group membership: %w", err)
+	}
+
+	return isMember, nil
+}
+
+// GetMemberRole returns entity.ErrGroupNotFound for a missing group and
+// entity.ErrNotGroupMember for a user outside an existing one.
+func (repo *GroupPostgresRepository) GetMemberRole(groupID uint, userID uint) (string, error) {
+	var roleName sql.NullString
+
+	err := repo.DB.QueryRow(
+		`SELECT r.name
+		FROM groups g
+		LEFT JOIN group_members gm ON gm.group_id = g.id AND gm.user_id = $2
+		LEFT JOIN roles r ON gm.role_id = r.id
+		WHERE g.id = $1`,
+		groupID, userID,
+	).Scan(&roleName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", entity.ErrGroupNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query member role: %w", err)
+	}
+	if !roleName.Valid {
+		return "", entity.ErrNotGroupMember
+	}
+
+	return roleName.String, nil
+}
+
+func (repo *GroupPostgresRepository) GetGroupTypeName(groupID uint) (string, error) {
+	var typeName string
+
+	err := repo.DB.QueryRow(
+		`SELECT gt.name
+		FROM groups g
+		JOIN group_types gt ON g.type_id = gt.id
+		WHERE g.id = $1`,
+		groupID,
+	).Scan(&typeName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", entity.ErrGroupNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query group type: %w", err)
+	}
+
+	return typeName, nil
+}
+
+func (repo *GroupPostgresRepository) AddMembers(groupID uint, groupMembers []entity.GroupMember) ([]uint, error) {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	addedIDs := make([]uint, 0, len(groupMembers))
+	for _, groupMember := range groupMembers {
+		var addedID uint
+		err = tx.QueryRow(
+			`INSERT INTO group_members (user_id, group_id, role_id)
+			VALUES ($1, $2, (SELECT id FROM roles WHERE name = $3))
+			ON CONFLICT (user_id, group_id) DO NOTHING
+			RETURNING user_id`,
+			groupMember.UserID, groupID, groupMember.Role,
+		).Scan(&addedID)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert group member %d: %w", groupMember.UserID, err)
+		}
+		addedIDs = append(addedIDs, addedID)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return addedIDs, nil
+}
+
+func (repo *GroupPostgresRepository) RemoveMember(groupID uint, userID uint) error {
+	result, err := repo.DB.Exec(
+		"DELETE FROM group_members WHERE group_id = $1 AND user_id = $2",
+		groupID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete group member: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return entity.ErrNotGroupMember
+	}
+
+	return nil
+}
+
+func (repo *GroupPostgresRepository) LeaveGroup(groupID uint, userID uint) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Leaving members of one group are serialized, so two last admins cannot leave at once.
+	var lockedID uint
+	err = tx.QueryRow("SELECT id FROM groups WHERE id = $1 FOR UPDATE", groupID).Scan(&lockedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.ErrGroupNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock group: %w", err)
+	}
+
+	var otherMembers, otherAdmins int
+	var isAdmin bool
+	err = tx.QueryRow(
+		`SELECT
+			COUNT(*) FILTER (WHERE gm.user_id <> $2),
+			COUNT(*) FILTER (WHERE gm.user_id <> $2 AND r.name = 'admin'),
+			COALESCE(BOOL_OR(gm.user_id = $2 AND r.name = 'admin'), false)
+		FROM group_members gm
+		JOIN roles r ON gm.role_id = r.id
+		WHERE gm.group_id = $1`,
+		groupID, userID,
+	).Scan(&otherMembers, &otherAdmins, &isAdmin)
+	if err != nil {
+		return fmt.Errorf("failed to count group admins: %w", err)
+	}
+
+	if isAdmin && otherAdmins == 0 && otherMembers > 0 {
+		return entity.ErrLastAdmin
+	}
+
+	result, err := tx.Exec(
+		"DELETE FROM group_members WHERE group_id = $1 AND user_id = $2",
+		groupID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete group member: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return entity.ErrNotGroupMember
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *GroupPostgresRepository) UpdateMemberRole(groupID uint, userID uint, roleName string) error {
+	result, err := repo.DB.Exec(
+		`UPDATE group_members
+		SET role_id = (SELECT id FROM roles WHERE name = $3)
+		WHERE group_id = $1 AND user_id = $2`,
+		groupID, userID, roleName,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update member role: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return entity.ErrNotGroupMember
+	}
+
+	return nil
+}
+
+func (repo *GroupPostgresRepository) UpdateName(groupID uint, name string) error {
+	result, err := repo.DB.Exec(
+		"UPDATE groups SET name = $2 WHERE id = $1",
+		groupID, name,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update group name: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return entity.ErrGroupNotFound
+	}
+
+	return nil
+}
+
+func (repo *GroupPostgresRepository) GetGroupsByUserID(userID uint, typeName string) ([]model.GroupSummary, error) {
 	query := `
         SELECT 
             g.id,
             g.name,
             g.creator_id,
-            g.type_id
+            g.type_id,
+            g.created_at,
+            gt.name,
+            r.name,
+            (SELECT COUNT(*) FROM group_members c WHERE c.group_id = g.id) AS member_count,
+            (
+                SELECT COUNT(*)
+                FROM messages m
+                WHERE m.group_id = g.id
+                    AND m.id > COALESCE(gm.last_read_message_id, 0)
+                    AND m.user_id <> gm.user_id
+                    AND m.deleted_at IS NULL
+            ) AS unread_count,
+            COALESCE(lm.created_at, g.created_at) AS last_activity_at,
+            lm.id,
+            lm.user_id,
+            LEFT(lm.content, $2),
+            lm.created_at,
+            lm.deleted_at IS NOT NULL,
+            CASE WHEN gt.name = 'personal' THEN (
+                SELECT p.user_id
+                FROM group_members p
+                WHERE p.group_id = g.id AND p.user_id <> gm.user_id
+                LIMIT 1
+            ) END AS peer_user_id
         FROM groups g
         JOIN group_types gt ON g.type_id = gt.id
         JOIN group_members gm ON g.id = gm.group_id
+        JOIN roles r ON gm.role_id = r.id
+        LEFT JOIN LATERAL (
+            SELECT m.id, m.user_id, m.content, m.created_at, m.deleted_at
+            FROM messages m
+            WHERE m.group_id = g.id
+            ORDER BY m.id DESC
+            LIMIT 1
+        ) lm ON TRUE
         WHERE gm.user_id = $1 
-            AND gt.name = 'group'`
+            AND ($3 = '' OR gt.name = $3)
+        ORDER BY last_activity_at DESC, g.id DESC`
 
-	rows, err := repo.DB.Query(query, userID)
+	rows, err := repo.DB.Query(query, userID, lastMessagePreviewLength, typeName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
 	defer rows.Close()
 
-	var groups []model.Group
+	var groups []model.GroupSummary
 	for rows.Next() {
-		var group model.Group
+		var group model.GroupSummary
 
 		err := rows.Scan(
 			&group.ID,
 			&group.Name,
 			&group.CreatorID,
 			&group.TypeID,
-			// &group.MemberCount, // TODO
+			&group.CreatedAt,
+			&group.TypeName,
+			&group.Role,
+			&group.MemberCount,
+			&group.UnreadCount,
+			&group.LastActivityAt,
+			&group.LastMessageID,
+			&group.LastMessageUserID,
+			&group.LastMessageContent,
+			&group.LastMessageCreatedAt,
+			&group.LastMessageDeleted,
+			&group.PeerUserID,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan group row: %w", err)
@@ -110,74 +438,137 @@ func (repo *GroupPostgresRepository) GetGroupsByUserID(userID uint) ([]model.Gro
 	return groups, nil
 }
 
-func (repo *GroupPostgresRepository) Create(groupEntity *entity.Group, groupMembers []entity.GroupMember) (*model.Group, error) {
-	createdGroupModel := &model.Group{}
-	var groupTypeID uint
+func (repo *GroupPostgresRepository) UpdateLastRead(groupID uint, userID uint, messageID uint) (uint, error) {
+	var lastReadMessageID uint
 
+	// Отметка прочтения только двигается вперёд
 	err := repo.DB.QueryRow(
-		"SELECT id FROM group_types WHERE name = $1",
-		groupEntity.TypeName,
-	).Scan(&groupTypeID)
+		`UPDATE group_members
+		SET last_read_message_id = GREATEST(COALESCE(last_read_message_id, 0), $3)
+		WHERE group_id = $1
+			AND user_id = $2
+			AND EXISTS (SELECT 1 FROM messages WHERE id = $3 AND group_id = $1)
+		RETURNING last_read_message_id`,
+		groupID, userID, messageID,
+	).Scan(&lastReadMessageID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, entity.ErrMessageNotInGroup
+	}
 	if err != nil {
-		return nil, err
+		return 0, fmt.Errorf("failed to update last read message: %w", err)
 	}
 
+	return lastReadMessageID, nil
+}
+
+func (repo *GroupPostgresRepository) Create(groupEntity *entity.Group, groupMembers []entity.GroupMember) (*model.Group, error) {
 	tx, err := repo.DB.Begin()
 	if err != nil {
 		return nil, err
 	}
 
+	createdGroupModel, err := insertGroup(tx, groupEntity, groupMembers)
+	if err != nil {
+		fmt.Println("Create group err")
+		rbErr := tx.Rollback()
+		if rbErr != nil {
+			fmt.Println("Rb err")
+		}
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		fmt.Println("commit err")
+		return nil, err
+	}
+
+	return createdGroupModel, nil
+}
+
+func (repo *GroupPostgresRepository) GetOrCreatePersonalGroup(groupEntity *entity.Group, groupMembers []entity.GroupMember) (uint, bool, error) {
+	if len(groupMembers) != 2 {
+		return 0, false, fmt.Errorf("personal group needs exactly 2 members, got %d", len(groupMembers))
+	}
+
+	user1ID, user2ID := groupMembers[0].UserID, groupMembers[1].UserID
+
+	groupID, err := repo.GetPersonalGroupID(user1ID, user2ID)
+	if err == nil {
+		return groupID, false, nil
+	}
+	if !errors.Is(err, entity.ErrGroupNotFound) {
+		return 0, false, err
+	}
+
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	createdGroupModel, err := insertGroup(tx, groupEntity, groupMembers)
+	if err != nil {
+		return 0, false, err
+	}
+
+	// Уникальность пары гарантирует БД: при гонке вставка пропускается
 	err = tx.QueryRow(
+		`INSERT INTO personal_groups (group_id, user_low_id, user_high_id)
+		VALUES ($1, LEAST($2::INTEGER, $3::INTEGER), GREATEST($2::INTEGER, $3::INTEGER))
+		ON CONFLICT (user_low_id, user_high_id) DO NOTHING
+		RETURNING group_id`,
+		createdGroupModel.ID, user1ID, user2ID,
+	).Scan(&groupID)
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := tx.Rollback(); err != nil {
+			return 0, false, fmt.Errorf("failed to rollback transaction: %w", err)
+		}
+
+		groupID, err = repo.GetPersonalGroupID(user1ID, user2ID)
+		return groupID, false, err
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to register personal group: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return groupID, true, nil
+}
+
+func insertGroup(tx *sql.Tx, groupEntity *entity.Group, groupMembers []entity.GroupMember) (*model.Group, error) {
+	createdGroupModel := &model.Group{}
+
+	err := tx.QueryRow(
 		`INSERT INTO groups (name, creator_id, type_id) 
-		VALUES ($1, $2, $3) 
-		RETURNING id, name, creator_id, type_id`,
-		groupEntity.Name, groupEntity.CreatorID, groupTypeID,
+		VALUES ($1, $2, (SELECT id FROM group_types WHERE name = $3)) 
+		RETURNING id, name, creator_id, type_id, created_at`,
+		groupEntity.Name, groupEntity.CreatorID, groupEntity.TypeName,
 	).Scan(
 		&createdGroupModel.ID,
 		&createdGroupModel.Name,
 		&createdGroupModel.CreatorID,
 		&createdGroupModel.TypeID,
+		&createdGroupModel.CreatedAt,
 	)
 	if err != nil {
-		fmt.Println("Create group err")
-		rbErr := tx.Rollback()
-		if rbErr != nil {
-			fmt.Println("Rb err")
-		}
 		return nil, err
 	}
 
 	for _, groupMember := range groupMembers {
-		var roleID uint
-
-		err := repo.DB.QueryRow(
-			"SELECT id FROM roles WHERE name = $1",
-			groupMember.Role,
-		).Scan(&roleID)
-		if err != nil {
-			return nil, err
-		}
-
 		_, err = tx.Exec(
 			`INSERT INTO group_members (user_id, group_id, role_id) 
-			VALUES ($1, $2, $3)`,
-			groupMember.UserID, createdGroupModel.ID, roleID,
+			VALUES ($1, $2, (SELECT id FROM roles WHERE name = $3))`,
+			groupMember.UserID, createdGroupModel.ID, groupMember.Role,
 		)
 		if err != nil {
 			fmt.Println("Create group member err")
-			rbErr := tx.Rollback()
-			if rbErr != nil {
-				fmt.Println("Rb err")
-			}
 			return nil, err
 		}
 	}
 
-	err = tx.Commit()
-	if err != nil {
-		fmt.Println("commit err")
-		return nil, err
-	}
-
 	return createdGroupModel, nil
 }
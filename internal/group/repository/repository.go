@@ -7,7 +7,23 @@ import (
 
 type GroupRepositoryI interface {
 	Create(groupEntity *entity.Group, groupMembers []entity.GroupMember) (*model.Group, error)
-	GetGroupsByUserID(userID uint) ([]model.Group, error)
+	// GetGroupsByUserID lists the user's groups of the given type, or of every type when typeName is empty.
+	GetGroupsByUserID(userID uint, typeName string) ([]model.GroupSummary, error)
 	GetPersonalGroupID(user1ID uint, user2ID uint) (uint, error)
+	// GetOrCreatePersonalGroup returns the personal group of the two given members, creating it if needed.
+	GetOrCreatePersonalGroup(groupEntity *entity.Group, groupMembers []entity.GroupMember) (groupID uint, created bool, err error)
+	GetByID(groupID uint) (*model.GroupSummary, error)
 	GetMemberIDsByGroupID(groupID uint) ([]uint, error)
+	GetMembers(groupID uint) ([]entity.GroupMember, error)
+	IsMember(groupID uint, userID uint) (bool, error)
+	GetMemberRole(groupID uint, userID uint) (string, error)
+	GetGroupTypeName(groupID uint) (string, error)
+	// AddMembers skips users who already are members and returns the ids it actually added.
+	AddMembers(groupID uint, groupMembers []entity.GroupMember) ([]uint, error)
+	RemoveMember(groupID uint, userID uint) error
+	// LeaveGroup removes the member unless they are the last admin of a group that still has other members.
+	LeaveGroup(groupID uint, userID uint) error
+	UpdateMemberRole(groupID uint, userID uint, roleName string) error
+	UpdateName(groupID uint, name string) error
+	UpdateLastRead(groupID uint, userID uint, messageID uint) (uint, error)
 }
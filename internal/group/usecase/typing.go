@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	TYPING_SIGNAL_TTL   = 5 * time.Second
+	TYPING_MIN_INTERVAL = 2 * time.Second
+
+	// Stale entries are swept at most this often, so the map only holds users who
+	// typed within the last interval and a call never scans it more than once per interval.
+	typingLimiterSweepInterval = time.Minute
+)
+
+type typingKey struct {
+	groupID uint
+	userID  uint
+}
+
+// typingLimiter lets each user send one typing signal per group every TYPING_MIN_INTERVAL.
+// It is per process, which is enough to keep a single client from flooding a group.
+type typingLimiter struct {
+	mu          sync.Mutex
+	lastSentAt  map[typingKey]time.Time
+	lastSweepAt time.Time
+}
+
+func newTypingLimiter() *typingLimiter {
+	return &typingLimiter{
+		lastSentAt: make(map[typingKey]time.Time),
+	}
+}
+
+func (l *typingLimiter) Allow(groupID, userID uint, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := typingKey{groupID: groupID, userID: userID}
+	if lastSentAt, ok := l.lastSentAt[key]; ok && now.Sub(lastSentAt) < TYPING_MIN_INTERVAL {
+		return false
+	}
+
+	if now.Sub(l.lastSweepAt) >= typingLimiterSweepInterval {
+		l.lastSweepAt = now
+		for trackedKey, lastSentAt := range l.lastSentAt {
+			if now.Sub(lastSentAt) >= TYPING_MIN_INTERVAL {
+				delete(l.lastSentAt, trackedKey)
+			}
+		}
+	}
+
+	l.lastSentAt[key] = now
+
+	return true
+}
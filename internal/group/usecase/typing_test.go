@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+)
+
+type typingCall struct {
+	groupID uint
+	userID  uint
+	after   time.Duration // since the first call
+	want    bool
+}
+
+func TestTypingLimiterAllow(t *testing.T) {
+	tests := []struct {
+		name  string
+		calls []typingCall
+	}{
+		{
+			name: "first signal is allowed",
+			calls: []typingCall{
+				{groupID: 1, userID: 1, want: true},
+			},
+		},
+		{
+			name: "repeat within the interval is rejected",
+			calls: []typingCall{
+				{groupID: 1, userID: 1, want: true},
+				{groupID: 1, userID: 1, after: TYPING_MIN_INTERVAL - time.Millisecond, want: false},
+			},
+		},
+		{
+			name: "repeat after the interval is allowed",
+			calls: []typingCall{
+				{groupID: 1, userID: 1, want: true},
+				{groupID: 1, userID: 1, after: TYPING_MIN_INTERVAL, want: true},
+			},
+		},
+		{
+			name: "rejected calls do not extend the interval",
+			calls: []typingCall{
+				{groupID: 1, userID: 1, want: true},
+				{groupID: 1, userID: 1, after: time.Second, want: false},
+				{groupID: 1, userID: 1, after: TYPING_MIN_INTERVAL, want: true},
+			},
+		},
+		{
+			name: "users and groups are limited separately",
+			calls: []typingCall{
+				{groupID: 1, userID: 1, want: true},
+				{groupID: 1, userID: 2, want: true},
+				{groupID: 2, userID: 1, want: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newTypingLimiter()
+			start := time.Now()
+
+			for i, call := range tt.calls {
+				if got := limiter.Allow(call.groupID, call.userID, start.Add(call.after)); got != call.want {
+					t.Fatalf("call %d: Allow() = %v, want %v", i, got, call.want)
+				}
+			}
+		})
+	}
+}
+
+func TestTypingLimiterSweep(t *testing.T) {
+	tests := []struct {
+		name        string
+		nextCallAt  time.Duration
+		wantTracked int
+	}{
+		{name: "no sweep before the interval", nextCallAt: typingLimiterSweepInterval - time.Second, wantTracked: 4},
+		{name: "stale entries are swept after the interval", nextCallAt: typingLimiterSweepInterval, wantTracked: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newTypingLimiter()
+			start := time.Now()
+
+			// The first call sweeps the empty map and starts the interval.
+			limiter.Allow(1, 1, start)
+			limiter.Allow(1, 2, start)
+			limiter.Allow(1, 3, start.Add(time.Second))
+
+			limiter.Allow(2, 1, start.Add(tt.nextCallAt))
+
+			if got := len(limiter.lastSentAt); got != tt.wantTracked {
+				t.Errorf("tracked entries = %d, want %d", got, tt.wantTracked)
+			}
+		})
+	}
+}
@@ -1,34 +1,82 @@
 package usecase
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"strconv"
+	"time"
 
+	"github.com/lightlink/group-service/infrastructure/ws"
+	"github.com/lightlink/group-service/internal/group/domain/dto"
 	"github.com/lightlink/group-service/internal/group/domain/entity"
+	"github.com/lightlink/group-service/internal/group/permission"
 	groupRepo "github.com/lightlink/group-service/internal/group/repository"
 	notificationDTO "github.com/lightlink/group-service/internal/notification/domain/dto"
 	notificationRepo "github.com/lightlink/group-service/internal/notification/repository"
+	presenceRepo "github.com/lightlink/group-service/internal/presence/repository"
 )
 
 type GroupUsecaseI interface {
 	Create(groupEntity *entity.Group, groupMembers []entity.GroupMember) error
 	GetGroupsByUserID(userID uint) ([]entity.Group, error)
+	GetConversationsByUserID(userID uint, typeName string) ([]entity.Group, error)
+	GetGroup(groupID uint) (*entity.Group, error)
+	ListMembers(groupID uint) ([]entity.GroupMember, error)
+	IsMember(groupID, userID uint) (bool, error)
 	GetPersonalGroupID(user1ID uint, user2ID uint) (uint, error)
+	GetOrCreatePersonalGroup(user1ID, user2ID uint) (groupID uint, created bool, err error)
 	StartCall(initiatorIDString, groupIDString string) error
+	AddMembers(initiatorID, groupID uint, groupMembers []entity.GroupMember) error
+	RemoveMember(initiatorID, groupID, userID uint) error
+	LeaveGroup(userID, groupID uint) error
+	ChangeMemberRole(initiatorID, groupID, userID uint, role string) error
+	Rename(initiatorID, groupID uint, name string) error
+	MarkRead(userID, groupID, messageID uint) error
+	StartTyping(userID, groupID uint) error
+	GetPresence(userID, groupID uint) ([]entity.MemberPresence, error)
+	SubscribeGroupEvents(userID, groupID uint) (<-chan ws.Event, func(), error)
+	IssueConnectionToken(userID uint) (*entity.ConnectionToken, error)
+	IssueGroupTokens(userID, groupID uint) (*entity.GroupTokens, error)
 }
 
+const (
+	PERSONAL_GROUP_TYPE = "personal"
+	GROUP_GROUP_TYPE    = "group"
+)
+
 type GroupUsecase struct {
-	groupRepo        groupRepo.GroupRepositoryI
-	notificationRepo notificationRepo.NotificationRepositoryI
+	groupRepo         groupRepo.GroupRepositoryI
+	notificationRepo  notificationRepo.NotificationRepositoryI
+	lastSeenRepo      presenceRepo.LastSeenRepositoryI
+	messagingServer   ws.MessagingServer
+	eventSubscriber   ws.GroupEventSubscriber
+	presenceProvider  ws.PresenceProvider
+	tokenIssuer       ws.TokenIssuer
+	permissionChecker *permission.Checker
+	typingLimiter     *typingLimiter
 }
 
 func NewGroupUsecase(
 	groupRepository groupRepo.GroupRepositoryI,
 	notificationRepo notificationRepo.NotificationRepositoryI,
+	lastSeenRepo presenceRepo.LastSeenRepositoryI,
+	messagingServer ws.MessagingServer,
+	eventSubscriber ws.GroupEventSubscriber,
+	presenceProvider ws.PresenceProvider,
+	tokenIssuer ws.TokenIssuer,
 ) *GroupUsecase {
 	return &GroupUsecase{
-		groupRepo:        groupRepository,
-		notificationRepo: notificationRepo,
+		groupRepo:         groupRepository,
+		notificationRepo:  notificationRepo,
+		lastSeenRepo:      lastSeenRepo,
+		messagingServer:   messagingServer,
+		eventSubscriber:   eventSubscriber,
+		presenceProvider:  presenceProvider,
+		tokenIssuer:       tokenIssuer,
+		permissionChecker: permission.NewChecker(groupRepository),
+		typingLimiter:     newTypingLimiter(),
 	}
 }
 
@@ -43,6 +91,11 @@ func (uc *GroupUsecase) StartCall(initiatorIDString, groupIDString string) error
 		return err
 	}
 
+	err = uc.permissionChecker.Check(uint(groupID), uint(initiatorID), permission.StartCall)
+	if err != nil {
+		return err
+	}
+
 	err = uc.sendIncomingCallNotification(uint(initiatorID), uint(groupID))
 	if err != nil {
 		return err
@@ -51,17 +104,24 @@ func (uc *GroupUsecase) StartCall(initiatorIDString, groupIDString string) error
 	return nil
 }
 
+// sendIncomingCallNotification rings online members right away through their user channels,
+// all in one broadcast, and notifies everyone through the notification service.
 func (uc *GroupUsecase) sendIncomingCallNotification(initiatorID, groupID uint) error {
 	memberIDs, err := uc.groupRepo.GetMemberIDsByGroupID(uint(groupID))
 	if err != nil {
 		return err
 	}
 
+	groupIDString := strconv.FormatUint(uint64(groupID), 10)
+	userChannels := make([]string, 0, len(memberIDs))
+
 	for _, memberID := range memberIDs {
 		if memberID == initiatorID {
 			continue
 		}
 
+		userChannels = append(userChannels, entity.UserChannel(groupIDString, strconv.FormatUint(uint64(memberID), 10)))
+
 		notifErr := uc.notificationRepo.Send(notificationDTO.RawNotification{
 			Type: "incomingCall",
 			Payload: map[string]interface{}{
@@ -75,10 +135,32 @@ func (uc *GroupUsecase) sendIncomingCallNotification(initiatorID, groupID uint)
 		}
 	}
 
+	if len(userChannels) > 0 {
+		err = uc.messagingServer.Broadcast(userChannels, dto.GroupSignal{
+			Type: "incomingCall",
+			Payload: dto.IncomingCallPayload{
+				GroupID:    groupID,
+				FromUserID: initiatorID,
+			},
+		})
+		if err != nil {
+			log.Printf("ERR: Failed to broadcast incoming call in group %d: %v\n", groupID, err)
+		}
+	}
+
 	return nil
 }
 
 func (uc *GroupUsecase) Create(groupEntity *entity.Group, groupMembers []entity.GroupMember) error {
+	for i := range groupMembers {
+		if groupMembers[i].Role == "" {
+			groupMembers[i].Role = permission.RoleMember
+		}
+		if !permission.IsKnownRole(groupMembers[i].Role) {
+			return entity.ErrInvalidRole
+		}
+	}
+
 	_, err := uc.groupRepo.Create(groupEntity, groupMembers)
 	if err != nil {
 		return err
@@ -88,7 +170,16 @@ func (uc *GroupUsecase) Create(groupEntity *entity.Group, groupMembers []entity.
 }
 
 func (uc *GroupUsecase) GetGroupsByUserID(userID uint) ([]entity.Group, error) {
-	groupModels, err := uc.groupRepo.GetGroupsByUserID(userID)
+	return uc.GetConversationsByUserID(userID, GROUP_GROUP_TYPE)
+}
+
+// GetConversationsByUserID lists personal and group chats; an empty typeName returns both kinds.
+func (uc *GroupUsecase) GetConversationsByUserID(userID uint, typeName string) ([]entity.Group, error) {
+	if typeName != "" && typeName != PERSONAL_GROUP_TYPE && typeName != GROUP_GROUP_TYPE {
+		return nil, entity.ErrInvalidGroupType
+	}
+
+	groupModels, err := uc.groupRepo.GetGroupsByUserID(userID, typeName)
 	if err != nil {
 		return nil, err
 	}
@@ -96,10 +187,26 @@ func (uc *GroupUsecase) GetGroupsByUserID(userID uint) ([]entity.Group, error) {
 	groupEntities := []entity.Group{}
 	for _, groupModel := range groupModels {
 		groupEntity := entity.Group{
-			ID:        groupModel.ID,
-			Name:      groupModel.Name,
-			CreatorID: groupModel.CreatorID,
-			TypeName:  "group", // TODO
+			ID:             groupModel.ID,
+			Name:           groupModel.Name,
+			CreatorID:      groupModel.CreatorID,
+			TypeName:       groupModel.TypeName,
+			CreatedAt:      groupModel.CreatedAt,
+			Role:           groupModel.Role,
+			MemberCount:    groupModel.MemberCount,
+			UnreadCount:    groupModel.UnreadCount,
+			LastActivityAt: groupModel.LastActivityAt,
+			PeerUserID:     groupModel.PeerUserID,
+		}
+
+		if groupModel.LastMessageID != nil {
+			groupEntity.LastMessage = &entity.LastMessage{
+				ID:        *groupModel.LastMessageID,
+				UserID:    *groupModel.LastMessageUserID,
+				Content:   *groupModel.LastMessageContent,
+				CreatedAt: *groupModel.LastMessageCreatedAt,
+				IsDeleted: groupModel.LastMessageDeleted,
+			}
 		}
 
 		groupEntities = append(groupEntities, groupEntity)
@@ -108,6 +215,34 @@ func (uc *GroupUsecase) GetGroupsByUserID(userID uint) ([]entity.Group, error) {
 	return groupEntities, nil
 }
 
+func (uc *GroupUsecase) GetGroup(groupID uint) (*entity.Group, error) {
+	groupModel, err := uc.groupRepo.GetByID(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.Group{
+		ID:          groupModel.ID,
+		Name:        groupModel.Name,
+		CreatorID:   groupModel.CreatorID,
+		TypeName:    groupModel.TypeName,
+		CreatedAt:   groupModel.CreatedAt,
+		MemberCount: groupModel.MemberCount,
+	}, nil
+}
+
+func (uc *GroupUsecase) ListMembers(groupID uint) ([]entity.GroupMember, error) {
+	if _, err := uc.groupRepo.GetByID(groupID); err != nil {
+		return nil, err
+	}
+
+	return uc.groupRepo.GetMembers(groupID)
+}
+
+func (uc *GroupUsecase) IsMember(groupID, userID uint) (bool, error) {
+	return uc.groupRepo.IsMember(groupID, userID)
+}
+
 func (uc *GroupUsecase) GetPersonalGroupID(user1ID uint, user2ID uint) (uint, error) {
 	groupID, err := uc.groupRepo.GetPersonalGroupID(user1ID, user2ID)
 	if err != nil {
@@ -116,3 +251,414 @@ func (uc *GroupUsecase) GetPersonalGroupID(user1ID uint, user2ID uint) (uint, er
 
 	return groupID, nil
 }
+
+func (uc *GroupUsecase) AddMembers(initiatorID, groupID uint, groupMembers []entity.GroupMember) error {
+	actions := []permission.Action{permission.ManageMembers}
+
+	for i := range groupMembers {
+		if groupMembers[i].Role == "" {
+			groupMembers[i].Role = permission.RoleMember
+		}
+		if !permission.IsKnownRole(groupMembers[i].Role) {
+			return entity.ErrInvalidRole
+		}
+		if groupMembers[i].Role != permission.RoleMember {
+			actions = append(actions, permission.ManageRoles)
+		}
+	}
+
+	if err := uc.checkMembershipEditable(initiatorID, groupID, actions...); err != nil {
+		return err
+	}
+
+	addedIDs, err := uc.groupRepo.AddMembers(groupID, groupMembers)
+	if err != nil {
+		return err
+	}
+
+	// Users who already were members are not announced again.
+	if len(addedIDs) == 0 {
+		return nil
+	}
+
+	uc.publishGroupSignal(groupID, dto.GroupSignal{
+		Type: "membersAdded",
+		Payload: dto.MembersAddedPayload{
+			GroupID:   groupID,
+			AddedByID: initiatorID,
+			UserIDs:   addedIDs,
+		},
+	})
+
+	return nil
+}
+
+func (uc *GroupUsecase) RemoveMember(initiatorID, groupID, userID uint) error {
+	// The initiator is authorized before the target is looked up, so outsiders cannot
+	// probe membership through the error they get.
+	if err := uc.checkMembershipEditable(initiatorID, groupID, permission.ManageMembers); err != nil {
+		return err
+	}
+
+	role, err := uc.groupRepo.GetMemberRole(groupID, userID)
+	if errors.Is(err, entity.ErrNotGroupMember) {
+		return entity.ErrMemberNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	// Only members who may hand out roles are allowed to remove privileged members.
+	if role != permission.RoleMember && role != permission.RoleReader {
+		if err := uc.permissionChecker.Check(groupID, initiatorID, permission.ManageRoles); err != nil {
+			return err
+		}
+	}
+
+	err = uc.groupRepo.RemoveMember(groupID, userID)
+	if errors.Is(err, entity.ErrNotGroupMember) {
+		return entity.ErrMemberNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	uc.publishGroupSignal(groupID, dto.GroupSignal{
+		Type: "memberRemoved",
+		Payload: dto.MemberRemovedPayload{
+			GroupID:     groupID,
+			RemovedByID: initiatorID,
+			UserID:      userID,
+		},
+	})
+
+	uc.revokeChannels(groupID, userID)
+
+	return nil
+}
+
+func (uc *GroupUsecase) LeaveGroup(userID, groupID uint) error {
+	typeName, err := uc.groupRepo.GetGroupTypeName(groupID)
+	if err != nil {
+		return err
+	}
+
+	if typeName == PERSONAL_GROUP_TYPE {
+		return entity.ErrPersonalGroupReadOnly
+	}
+
+	if err := uc.groupRepo.LeaveGroup(groupID, userID); err != nil {
+		return err
+	}
+
+	uc.publishGroupSignal(groupID, dto.GroupSignal{
+		Type: "memberLeft",
+		Payload: dto.MemberRemovedPayload{
+			GroupID:     groupID,
+			RemovedByID: userID,
+			UserID:      userID,
+		},
+	})
+
+	uc.revokeChannels(groupID, userID)
+
+	return nil
+}
+
+func (uc *GroupUsecase) ChangeMemberRole(initiatorID, groupID, userID uint, role string) error {
+	if !permission.IsKnownRole(role) {
+		return entity.ErrInvalidRole
+	}
+
+	// Changing one's own role could leave the group without anyone able to manage it.
+	if initiatorID == userID {
+		return entity.ErrPermissionDenied
+	}
+
+	if err := uc.checkMembershipEditable(initiatorID, groupID, permission.ManageRoles); err != nil {
+		return err
+	}
+
+	err := uc.groupRepo.UpdateMemberRole(groupID, userID, role)
+	if errors.Is(err, entity.ErrNotGroupMember) {
+		return entity.ErrMemberNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	uc.publishGroupSignal(groupID, dto.GroupSignal{
+		Type: "memberRoleChanged",
+		Payload: dto.MemberRoleChangedPayload{
+			GroupID:     groupID,
+			ChangedByID: initiatorID,
+			UserID:      userID,
+			Role:        role,
+		},
+	})
+
+	return nil
+}
+
+func (uc *GroupUsecase) Rename(initiatorID, groupID uint, name string) error {
+	if name == "" {
+		return entity.ErrInvalidGroupName
+	}
+
+	if err := uc.checkMembershipEditable(initiatorID, groupID, permission.RenameGroup); err != nil {
+		return err
+	}
+
+	if err := uc.groupRepo.UpdateName(groupID, name); err != nil {
+		return err
+	}
+
+	uc.publishGroupSignal(groupID, dto.GroupSignal{
+		Type: "groupRenamed",
+		Payload: dto.GroupRenamedPayload{
+			GroupID:     groupID,
+			RenamedByID: initiatorID,
+			Name:        name,
+		},
+	})
+
+	return nil
+}
+
+func (uc *GroupUsecase) MarkRead(userID, groupID, messageID uint) error {
+	if err := uc.permissionChecker.RequireMember(groupID, userID); err != nil {
+		return err
+	}
+
+	lastReadMessageID, err := uc.groupRepo.UpdateLastRead(groupID, userID, messageID)
+	if err != nil {
+		return err
+	}
+
+	uc.publishGroupSignal(groupID, dto.GroupSignal{
+		Type: "readReceipt",
+		Payload: dto.ReadReceiptPayload{
+			GroupID:           groupID,
+			UserID:            userID,
+			LastReadMessageID: lastReadMessageID,
+		},
+	})
+
+	return nil
+}
+
+// StartTyping tells the group that the user is typing. Only members who may send messages
+// can do it, and the signal expires on its own after TYPING_SIGNAL_TTL.
+func (uc *GroupUsecase) StartTyping(userID, groupID uint) error {
+	// Permission goes first so that rejected calls do not take up the user's slot.
+	if err := uc.permissionChecker.Check(groupID, userID, permission.SendMessage); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if !uc.typingLimiter.Allow(groupID, userID, now) {
+		return entity.ErrRateLimited
+	}
+
+	uc.publishGroupSignal(groupID, dto.GroupSignal{
+		Type: "typing",
+		Payload: dto.TypingPayload{
+			GroupID:   groupID,
+			UserID:    userID,
+			ExpiresAt: now.Add(TYPING_SIGNAL_TTL),
+		},
+	})
+
+	return nil
+}
+
+// GetPresence reports which members are subscribed to the group's messages channel right now,
+// and when the others were last seen.
+func (uc *GroupUsecase) GetPresence(userID, groupID uint) ([]entity.MemberPresence, error) {
+	if err := uc.permissionChecker.RequireMember(groupID, userID); err != nil {
+		return nil, err
+	}
+
+	memberIDs, err := uc.groupRepo.GetMemberIDsByGroupID(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	channel := entity.GroupChannel(strconv.FormatUint(uint64(groupID), 10))
+	onlineUserIDs, err := uc.presenceProvider.PresenceUserIDs(channel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get presence of %s: %w", channel, err)
+	}
+
+	online := make(map[uint]bool, len(onlineUserIDs))
+	for _, onlineUserID := range onlineUserIDs {
+		id, err := strconv.ParseUint(onlineUserID, 10, 32)
+		if err != nil {
+			continue
+		}
+		online[uint(id)] = true
+	}
+
+	lastSeen, err := uc.lastSeenRepo.GetLastSeen(memberIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]entity.MemberPresence, 0, len(memberIDs))
+	for _, memberID := range memberIDs {
+		member := entity.MemberPresence{
+			UserID: memberID,
+			Online: online[memberID],
+		}
+		if lastSeenAt, ok := lastSeen[memberID]; ok {
+			member.LastSeenAt = &lastSeenAt
+		}
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+func (uc *GroupUsecase) checkMembershipEditable(initiatorID, groupID uint, actions ...permission.Action) error {
+	typeName, err := uc.groupRepo.GetGroupTypeName(groupID)
+	if err != nil {
+		return err
+	}
+
+	if typeName == PERSONAL_GROUP_TYPE {
+		return entity.ErrPersonalGroupReadOnly
+	}
+
+	return uc.permissionChecker.Check(groupID, initiatorID, actions...)
+}
+
+func (uc *GroupUsecase) SubscribeGroupEvents(userID, groupID uint) (<-chan ws.Event, func(), error) {
+	if err := uc.permissionChecker.RequireMember(groupID, userID); err != nil {
+		return nil, nil, err
+	}
+
+	events, unsubscribe := uc.eventSubscriber.SubscribeGroup(groupID)
+
+	return events, unsubscribe, nil
+}
+
+// RevokesMembership reports whether the event removes userID from the group,
+// after which a subscriber's event stream has to end.
+func RevokesMembership(event ws.Event, userID uint) bool {
+	if event.Type != "memberRemoved" && event.Type != "memberLeft" {
+		return false
+	}
+
+	var payload dto.MemberRemovedPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return false
+	}
+
+	return payload.UserID == userID
+}
+
+// IssueConnectionToken also records activity: token-based connections skip the connect and
+// refresh proxies, so the token refresh is the only place their last-seen gets updated.
+func (uc *GroupUsecase) IssueConnectionToken(userID uint) (*entity.ConnectionToken, error) {
+	if err := uc.lastSeenRepo.Touch(userID); err != nil {
+		log.Printf("ERR: Failed to record last seen for user %d: %v\n", userID, err)
+	}
+
+	token, expiresAt, err := uc.tokenIssuer.ConnectionToken(strconv.FormatUint(uint64(userID), 10))
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.ConnectionToken{
+		Token:     token,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// IssueGroupTokens is also used to refresh tokens, so membership is checked every time.
+// Channel access itself is checked by the subscribe proxy on every subscription. There are
+// deliberately no per-channel subscription tokens: Centrifugo skips the subscribe proxy for
+// token subscriptions, so such a token would outlive a removal from the group.
+func (uc *GroupUsecase) IssueGroupTokens(userID, groupID uint) (*entity.GroupTokens, error) {
+	if err := uc.permissionChecker.RequireMember(groupID, userID); err != nil {
+		return nil, err
+	}
+
+	connectionToken, err := uc.IssueConnectionToken(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDString := strconv.FormatUint(uint64(userID), 10)
+	groupIDString := strconv.FormatUint(uint64(groupID), 10)
+
+	return &entity.GroupTokens{
+		ConnectionToken: *connectionToken,
+		Channels: map[string]string{
+			"room":           entity.RoomChannel(groupIDString),
+			"group_messages": entity.GroupChannel(groupIDString),
+			"user":           entity.UserChannel(groupIDString, userIDString),
+		},
+	}, nil
+}
+
+// revokeChannels drops a former member's live subscriptions to the group's channels;
+// new subscriptions are rejected by the subscribe proxy.
+func (uc *GroupUsecase) revokeChannels(groupID, userID uint) {
+	userIDString := strconv.FormatUint(uint64(userID), 10)
+	groupIDString := strconv.FormatUint(uint64(groupID), 10)
+
+	channels := []string{
+		entity.RoomChannel(groupIDString),
+		entity.GroupChannel(groupIDString),
+		entity.UserChannel(groupIDString, userIDString),
+	}
+
+	commands := make([]ws.Command, 0, len(channels))
+	for _, channel := range channels {
+		commands = append(commands, ws.Command{
+			Unsubscribe: &ws.UnsubscribeCommand{UserID: userIDString, Channel: channel},
+		})
+	}
+
+	if err := uc.messagingServer.Batch(commands); err != nil {
+		log.Printf("ERR: Failed to unsubscribe user %d from group %d channels: %v\n", userID, groupID, err)
+	}
+}
+
+// publishGroupSignal publishes without history: membership, read and typing signals are not
+// recovered after a reconnect, clients reload that state instead.
+func (uc *GroupUsecase) publishGroupSignal(groupID uint, signal dto.GroupSignal) {
+	err := uc.messagingServer.PublishEphemeralToGroup(groupID, signal)
+	if err != nil {
+		log.Printf("ERR: Failed to publish %s signal in group %d: %v\n", signal.Type, groupID, err)
+	}
+}
+
+func (uc *GroupUsecase) GetOrCreatePersonalGroup(user1ID, user2ID uint) (uint, bool, error) {
+	if user1ID == user2ID {
+		return 0, false, entity.ErrSelfPersonalGroup
+	}
+
+	lowID, highID := user1ID, user2ID
+	if lowID > highID {
+		lowID, highID = highID, lowID
+	}
+
+	groupEntity := &entity.Group{
+		Name:      fmt.Sprintf("personal-%d-%d", lowID, highID),
+		CreatorID: user1ID,
+		TypeName:  PERSONAL_GROUP_TYPE,
+	}
+
+	return uc.groupRepo.GetOrCreatePersonalGroup(groupEntity, []entity.GroupMember{
+		{
+			UserID: user1ID,
+			Role:   permission.RoleAdmin,
+		},
+		{
+			UserID: user2ID,
+			Role:   permission.RoleAdmin,
+		},
+	})
+}
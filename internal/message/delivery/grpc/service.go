@@ -0,0 +1,108 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	groupEntity "github.com/lightlink/group-service/internal/group/domain/entity"
+	"github.com/lightlink/group-service/internal/message/domain/dto"
+	"github.com/lightlink/group-service/internal/message/domain/entity"
+	"github.com/lightlink/group-service/internal/message/usecase"
+	proto "github.com/lightlink/group-service/protogen/message"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type MessageService struct {
+	proto.UnimplementedMessageServiceServer
+	messageUC usecase.MessageUsecaseI
+}
+
+func NewMessageService(uc usecase.MessageUsecaseI) *MessageService {
+	return &MessageService{
+		messageUC: uc,
+	}
+}
+
+// SendMessage posts a text message on behalf of user_id, who must be allowed to write to the group.
+// Attachments are only accepted over HTTP.
+func (ms *MessageService) SendMessage(ctx context.Context, request *proto.SendMessageRequest) (*proto.SendMessageResponse, error) {
+	if request.UserId == 0 || request.GroupId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user id and group id are required")
+	}
+
+	if request.Content == "" {
+		return nil, status.Error(codes.InvalidArgument, entity.ErrEmptyContent.Error())
+	}
+
+	createRequest := dto.CreateMessageRequest{
+		UserID:  uint(request.UserId),
+		GroupID: uint(request.GroupId),
+		Content: request.Content,
+	}
+
+	if request.ReplyToMessageId != 0 {
+		replyTo := uint(request.ReplyToMessageId)
+		createRequest.ReplyToMessageID = &replyTo
+	}
+
+	message, err := ms.messageUC.Create(&createRequest)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &proto.SendMessageResponse{Message: dto.MessageEntityToProto(*message)}, nil
+}
+
+func (ms *MessageService) ListMessages(ctx context.Context, request *proto.ListMessagesRequest) (*proto.ListMessagesResponse, error) {
+	cursor := entity.MessageCursor{
+		BeforeID: uint(request.BeforeId),
+		AfterID:  uint(request.AfterId),
+		Limit:    int(request.Limit),
+	}
+
+	page, err := ms.messageUC.GetByGroupID(uint(request.UserId), uint(request.GroupId), cursor)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	response := &proto.ListMessagesResponse{
+		Messages: make([]*proto.Message, 0, len(page.Messages)),
+	}
+	for _, message := range page.Messages {
+		response.Messages = append(response.Messages, dto.MessageEntityToProto(message))
+	}
+
+	if page.NextCursor != nil {
+		response.NextCursor = uint32(*page.NextCursor)
+		response.HasMore = true
+	}
+
+	return response, nil
+}
+
+func (ms *MessageService) GetMessage(ctx context.Context, request *proto.GetMessageRequest) (*proto.GetMessageResponse, error) {
+	message, err := ms.messageUC.GetByID(uint(request.UserId), uint(request.MessageId))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &proto.GetMessageResponse{Message: dto.MessageEntityToProto(*message)}, nil
+}
+
+func toStatusError(err error) error {
+	switch {
+	case errors.Is(err, entity.ErrMessageNotFound),
+		errors.Is(err, groupEntity.ErrGroupNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, groupEntity.ErrNotGroupMember),
+		errors.Is(err, groupEntity.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, entity.ErrEmptyContent),
+		errors.Is(err, entity.ErrInvalidReply),
+		errors.Is(err, entity.ErrInvalidCursor):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
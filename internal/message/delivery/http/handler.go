@@ -2,15 +2,22 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"github.com/lightlink/group-service/internal/auth"
+	groupEntity "github.com/lightlink/group-service/internal/group/domain/entity"
 	"github.com/lightlink/group-service/internal/message/domain/dto"
+	"github.com/lightlink/group-service/internal/message/domain/entity"
 	"github.com/lightlink/group-service/internal/message/usecase"
 )
 
+// NEXT_CURSOR_HEADER carries the cursor of the next history page; it is absent on the last page.
+const NEXT_CURSOR_HEADER = "X-Next-Cursor"
+
 type MessageHandler struct {
 	messageUC usecase.MessageUsecaseI
 }
@@ -27,13 +34,11 @@ func (h *MessageHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userIDStr := r.Header.Get("X-User-ID")
-	userID64, err := strconv.ParseUint(userIDStr, 10, 32)
-	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
-	userID := uint(userID64)
 
 	groupIDStr := r.FormValue("group_id")
 	groupID64, _ := strconv.ParseUint(groupIDStr, 10, 32)
@@ -50,7 +55,25 @@ func (h *MessageHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		Files:   files,
 	}
 
+	if replyToStr := r.FormValue("reply_to_message_id"); replyToStr != "" {
+		replyTo64, err := strconv.ParseUint(replyToStr, 10, 32)
+		if err != nil {
+			http.Error(w, "Invalid reply message ID", http.StatusBadRequest)
+			return
+		}
+		replyTo := uint(replyTo64)
+		createMessageRequest.ReplyToMessageID = &replyTo
+	}
+
 	message, err := h.messageUC.Create(&createMessageRequest)
+	if isAccessError(err) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, entity.ErrInvalidReply) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		/*Handle*/
 		w.WriteHeader(http.StatusBadRequest)
@@ -72,6 +95,12 @@ func (h *MessageHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *MessageHandler) GetGroupMessages(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	groupIDString := mux.Vars(r)["groupID"]
 	groupID64, err := strconv.ParseUint(groupIDString, 10, 32)
 	if err != nil {
@@ -82,14 +111,24 @@ func (h *MessageHandler) GetGroupMessages(w http.ResponseWriter, r *http.Request
 
 	groupID := uint(groupID64)
 
-	messages, err := h.messageUC.GetByGroupID(groupID)
+	cursor, err := parseMessageCursor(r)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Println(err)
+		http.Error(w, "Invalid pagination parameters", http.StatusBadRequest)
 		return
 	}
 
-	response, err := json.Marshal(messages)
+	page, err := h.messageUC.GetByGroupID(userID, groupID, cursor)
+	if err != nil {
+		writeUsecaseError(w, "Failed to get messages", err)
+		return
+	}
+
+	// The body stays the bare message array existing clients expect; the cursor goes in a header.
+	if page.NextCursor != nil {
+		w.Header().Set(NEXT_CURSOR_HEADER, strconv.FormatUint(uint64(*page.NextCursor), 10))
+	}
+
+	response, err := json.Marshal(page.Messages)
 	if err != nil {
 		/*Handle*/
 		fmt.Println(err)
@@ -101,3 +140,248 @@ func (h *MessageHandler) GetGroupMessages(w http.ResponseWriter, r *http.Request
 		fmt.Println("Failed to write get messages response")
 	}
 }
+
+func (h *MessageHandler) SyncMessages(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	groupID64, err := strconv.ParseUint(mux.Vars(r)["groupID"], 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+
+	var since int64
+	if sinceString := query.Get("since"); sinceString != "" {
+		since, err = strconv.ParseInt(sinceString, 10, 64)
+		if err != nil || since < 0 {
+			http.Error(w, "Invalid sync cursor", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var limit int
+	if limitString := query.Get("limit"); limitString != "" {
+		limit, err = strconv.Atoi(limitString)
+		if err != nil || limit < 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	changes, err := h.messageUC.Sync(userID, uint(groupID64), since, limit)
+	if err != nil {
+		writeUsecaseError(w, "Failed to sync messages", err)
+		return
+	}
+
+	response, err := json.Marshal(changes)
+	if err != nil {
+		/*Handle*/
+		fmt.Println(err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(response); err != nil {
+		fmt.Println("Failed to write sync messages response")
+	}
+}
+
+func (h *MessageHandler) GetThread(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	messageID64, err := strconv.ParseUint(mux.Vars(r)["messageID"], 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	thread, err := h.messageUC.GetThread(userID, uint(messageID64))
+	if err != nil {
+		writeUsecaseError(w, "Failed to get thread", err)
+		return
+	}
+
+	response, err := json.Marshal(thread)
+	if err != nil {
+		/*Handle*/
+		fmt.Println(err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(response); err != nil {
+		fmt.Println("Failed to write get thread response")
+	}
+}
+
+func (h *MessageHandler) UpdateMessage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	messageID64, err := strconv.ParseUint(mux.Vars(r)["messageID"], 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.UpdateMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	message, err := h.messageUC.Update(userID, uint(messageID64), req.Content)
+	if err != nil {
+		writeUsecaseError(w, "Failed to update message", err)
+		return
+	}
+
+	response, err := json.Marshal(message)
+	if err != nil {
+		/*Handle*/
+		fmt.Println(err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(response); err != nil {
+		fmt.Println("Failed to write update message response")
+	}
+}
+
+func (h *MessageHandler) DeleteMessage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	messageID64, err := strconv.ParseUint(mux.Vars(r)["messageID"], 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.messageUC.Delete(userID, uint(messageID64)); err != nil {
+		writeUsecaseError(w, "Failed to delete message", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *MessageHandler) AddReaction(w http.ResponseWriter, r *http.Request) {
+	h.handleReaction(w, r, h.messageUC.AddReaction)
+}
+
+func (h *MessageHandler) RemoveReaction(w http.ResponseWriter, r *http.Request) {
+	h.handleReaction(w, r, h.messageUC.RemoveReaction)
+}
+
+func (h *MessageHandler) handleReaction(
+	w http.ResponseWriter,
+	r *http.Request,
+	apply func(userID, messageID uint, emoji string) ([]entity.Reaction, error),
+) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	messageID64, err := strconv.ParseUint(mux.Vars(r)["messageID"], 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.ReactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	reactions, err := apply(userID, uint(messageID64), req.Emoji)
+	if err != nil {
+		writeUsecaseError(w, "Failed to update reaction", err)
+		return
+	}
+
+	response, err := json.Marshal(reactions)
+	if err != nil {
+		/*Handle*/
+		fmt.Println(err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(response); err != nil {
+		fmt.Println("Failed to write reactions response")
+	}
+}
+
+func writeUsecaseError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, entity.ErrMessageNotFound),
+		errors.Is(err, groupEntity.ErrGroupNotFound):
+		http.Error(w, message+": "+err.Error(), http.StatusNotFound)
+	case errors.Is(err, entity.ErrEmptyContent),
+		errors.Is(err, entity.ErrInvalidEmoji),
+		errors.Is(err, entity.ErrInvalidCursor):
+		http.Error(w, message+": "+err.Error(), http.StatusBadRequest)
+	case isAccessError(err):
+		http.Error(w, message+": "+err.Error(), http.StatusForbidden)
+	default:
+		fmt.Println(message+":", err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+func isAccessError(err error) bool {
+	return errors.Is(err, groupEntity.ErrNotGroupMember) || errors.Is(err, groupEntity.ErrPermissionDenied)
+}
+
+func parseMessageCursor(r *http.Request) (entity.MessageCursor, error) {
+	query := r.URL.Query()
+	cursor := entity.MessageCursor{}
+
+	if before := query.Get("before"); before != "" {
+		beforeID, err := strconv.ParseUint(before, 10, 32)
+		if err != nil {
+			return cursor, err
+		}
+		cursor.BeforeID = uint(beforeID)
+	}
+
+	if after := query.Get("after"); after != "" {
+		afterID, err := strconv.ParseUint(after, 10, 32)
+		if err != nil {
+			return cursor, err
+		}
+		cursor.AfterID = uint(afterID)
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		limitValue, err := strconv.Atoi(limit)
+		if err != nil || limitValue < 0 {
+			return cursor, fmt.Errorf("invalid limit %q", limit)
+		}
+		cursor.Limit = limitValue
+	}
+
+	return cursor, nil
+}
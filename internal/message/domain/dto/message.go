@@ -1,24 +1,42 @@
 package dto
 
-import "mime/multipart"
+import (
+	"mime/multipart"
+	"time"
+
+	"github.com/lightlink/group-service/internal/message/domain/entity"
+)
 
 type CreateMessageRequest struct {
-	UserID  uint                    `json:"user_id"`
-	GroupID uint                    `json:"group_id"`
-	Content string                  `json:"content"`
-	Files   []*multipart.FileHeader `form:"files"`
+	UserID           uint                    `json:"user_id"`
+	GroupID          uint                    `json:"group_id"`
+	Content          string                  `json:"content"`
+	ReplyToMessageID *uint                   `json:"reply_to_message_id"`
+	Files            []*multipart.FileHeader `form:"files"`
 }
 
-type MessageHateSpeechRequest struct {
-	ID      uint   `json:"id"`
-	GroupID uint   `json:"group_id"`
+type UpdateMessageRequest struct {
 	Content string `json:"content"`
 }
 
+type ReactionRequest struct {
+	Emoji string `json:"emoji"`
+}
+
+// ContentVersion must be echoed back in the response: verdicts for a version that has
+// since been edited are ignored.
+type MessageHateSpeechRequest struct {
+	ID             uint   `json:"id"`
+	GroupID        uint   `json:"group_id"`
+	Content        string `json:"content"`
+	ContentVersion int    `json:"content_version"`
+}
+
 type MessageHateSpeechResponse struct {
-	ID           uint `json:"id"`
-	GroupID      uint `json:"group_id"`
-	IsHateSpeech bool `json:"is_hate_speech"`
+	ID             uint `json:"id"`
+	GroupID        uint `json:"group_id"`
+	IsHateSpeech   bool `json:"is_hate_speech"`
+	ContentVersion int  `json:"content_version"`
 }
 
 type MessageSignal struct {
@@ -34,14 +52,62 @@ type FileInfo struct {
 }
 
 type IncomingMessagePayload struct {
-	ID      uint       `json:"id"`
-	UserID  uint       `json:"user_id"`
-	GroupID uint       `json:"group_id"`
-	Status  string     `json:"status"`
-	Content string     `json:"content"`
-	Files   []FileInfo `json:"files"`
+	ID               uint                   `json:"id"`
+	UserID           uint                   `json:"user_id"`
+	GroupID          uint                   `json:"group_id"`
+	Status           string                 `json:"status"`
+	Content          string                 `json:"content"`
+	Files            []FileInfo             `json:"files"`
+	ReplyToMessageID *uint                  `json:"reply_to_message_id"`
+	ReplyTo          *entity.MessagePreview `json:"reply_to,omitempty"`
+	ChangeSeq        int64                  `json:"change_seq"`
 }
 
 type HateSpeechStatusAckPayload struct {
 	MessageID uint `json:"message_id"`
 }
+
+type MessageEditedPayload struct {
+	ID       uint      `json:"id"`
+	GroupID  uint      `json:"group_id"`
+	Status   string    `json:"status"`
+	Content  string    `json:"content"`
+	EditedAt time.Time `json:"edited_at"`
+}
+
+// MessageDeletedPayload has no DeletedBy when the deletion is replayed from history.
+type MessageDeletedPayload struct {
+	MessageID uint `json:"message_id"`
+	GroupID   uint `json:"group_id"`
+	DeletedBy uint `json:"deleted_by,omitempty"`
+}
+
+type ReactionUpdatedPayload struct {
+	MessageID uint              `json:"message_id"`
+	GroupID   uint              `json:"group_id"`
+	Reactions []entity.Reaction `json:"reactions"`
+}
+
+func MessageEntityToIncomingPayload(message entity.Message) IncomingMessagePayload {
+	files := make([]FileInfo, 0, len(message.Files))
+	for _, file := range message.Files {
+		files = append(files, FileInfo{
+			Name: file.OriginalName,
+			URL:  file.URL,
+			Type: file.ContentType,
+			Size: file.Size,
+		})
+	}
+
+	return IncomingMessagePayload{
+		ID:               message.ID,
+		UserID:           message.UserID,
+		GroupID:          message.GroupID,
+		Status:           message.Status,
+		Content:          message.Content,
+		Files:            files,
+		ReplyToMessageID: message.ReplyToMessageID,
+		ReplyTo:          message.ReplyTo,
+		ChangeSeq:        message.ChangeSeq,
+	}
+}
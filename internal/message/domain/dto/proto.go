@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"github.com/lightlink/group-service/internal/message/domain/entity"
+	proto "github.com/lightlink/group-service/protogen/message"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func MessageEntityToProto(message entity.Message) *proto.Message {
+	protoMessage := &proto.Message{
+		Id:        uint32(message.ID),
+		UserId:    uint32(message.UserID),
+		GroupId:   uint32(message.GroupID),
+		Content:   message.Content,
+		Status:    message.Status,
+		CreatedAt: timestamppb.New(message.CreatedAt),
+		IsDeleted: message.IsDeleted(),
+		Files:     make([]*proto.File, 0, len(message.Files)),
+	}
+
+	if message.EditedAt != nil {
+		protoMessage.EditedAt = timestamppb.New(*message.EditedAt)
+	}
+
+	if message.ReplyToMessageID != nil {
+		protoMessage.ReplyToMessageId = uint32(*message.ReplyToMessageID)
+	}
+
+	for _, file := range message.Files {
+		protoMessage.Files = append(protoMessage.Files, &proto.File{
+			Name: file.OriginalName,
+			Url:  file.URL,
+			Type: file.ContentType,
+			Size: file.Size,
+		})
+	}
+
+	return protoMessage
+}
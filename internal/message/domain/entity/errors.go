@@ -0,0 +1,11 @@
+package entity
+
+import "errors"
+
+var (
+	ErrMessageNotFound = errors.New("message not found")
+	ErrEmptyContent    = errors.New("message content is required")
+	ErrInvalidReply    = errors.New("replied message does not belong to the group")
+	ErrInvalidEmoji    = errors.New("invalid reaction emoji")
+	ErrInvalidCursor   = errors.New("after must be lower than before")
+)
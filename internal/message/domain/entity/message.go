@@ -5,13 +5,47 @@ import (
 )
 
 type Message struct {
-	ID        uint      `json:"id"`
-	UserID    uint      `json:"user_id"`
-	GroupID   uint      `json:"group_id"`
-	Content   string    `json:"content"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
-	Files     []File    `json:"files"`
+	ID        uint       `json:"id"`
+	UserID    uint       `json:"user_id"`
+	GroupID   uint       `json:"group_id"`
+	Content   string     `json:"content"`
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at"`
+	DeletedAt *time.Time `json:"deleted_at"`
+	ChangeSeq int64      `json:"change_seq"`
+	Files     []File     `json:"files"`
+	Reactions []Reaction `json:"reactions"`
+
+	ReplyToMessageID *uint           `json:"reply_to_message_id"`
+	ReplyTo          *MessagePreview `json:"reply_to,omitempty"`
+
+	// ContentVersion counts edits, so a hate speech verdict can be matched to the content it checked.
+	ContentVersion int `json:"-"`
+}
+
+// MessagePreview is the quoted part of a replied message.
+type MessagePreview struct {
+	ID        uint   `json:"id"`
+	UserID    uint   `json:"user_id"`
+	Content   string `json:"content"`
+	IsDeleted bool   `json:"is_deleted"`
+}
+
+// Reaction aggregates every user's reaction with the same emoji on a message.
+type Reaction struct {
+	Emoji   string `json:"emoji"`
+	Count   int    `json:"count"`
+	UserIDs []uint `json:"user_ids"`
+}
+
+type Thread struct {
+	Root    Message   `json:"root"`
+	Replies []Message `json:"replies"`
+}
+
+func (m *Message) IsDeleted() bool {
+	return m.DeletedAt != nil
 }
 
 type File struct {
@@ -22,3 +56,50 @@ type File struct {
 	Size         int64  `json:"size"`
 	URL          string `json:"url"`
 }
+
+// MessageCursor selects a page of a group's history by message id.
+// With AfterID set the page is read forward, otherwise it ends right before BeforeID
+// (or at the newest message when BeforeID is zero). With both set the page is read
+// forward within the range between them.
+type MessageCursor struct {
+	BeforeID uint
+	AfterID  uint
+	Limit    int
+}
+
+func (c MessageCursor) Forward() bool {
+	return c.AfterID != 0
+}
+
+func (c MessageCursor) Validate() error {
+	if c.AfterID != 0 && c.BeforeID != 0 && c.AfterID >= c.BeforeID {
+		return ErrInvalidCursor
+	}
+
+	return nil
+}
+
+// MessagePage holds messages in ascending id order. NextCursor is the id to pass as
+// "before" (or "after" when reading forward) to continue, and is nil on the last page.
+type MessagePage struct {
+	Messages   []Message `json:"messages"`
+	NextCursor *uint     `json:"next_cursor"`
+}
+
+// StreamPosition is the offset and epoch of a channel's Centrifugo history stream.
+type StreamPosition struct {
+	Offset uint64 `json:"offset"`
+	Epoch  string `json:"epoch"`
+}
+
+// MessageChanges lists a group's messages changed after a sync cursor: new, edited, re-labelled
+// or re-reacted ones in Messages, deleted ones in DeletedMessageIDs. Cursor is the value to sync
+// from next time. Stream is the position of the group's messages channel taken before the changes
+// were read; subscribing with recovery from the last page's Stream loses no publication.
+type MessageChanges struct {
+	Messages          []Message       `json:"messages"`
+	DeletedMessageIDs []uint          `json:"deleted_message_ids"`
+	Cursor            int64           `json:"cursor"`
+	HasMore           bool            `json:"has_more"`
+	Stream            *StreamPosition `json:"stream,omitempty"`
+}
@@ -3,10 +3,14 @@ package model
 import "time"
 
 type Message struct {
-	ID        uint      `db:"id"`
-	UserID    uint      `db:"user_id"`
-	GroupID   uint      `db:"group_id"`
-	StatusID  uint      `db:"status_id"`
-	Content   string    `db:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        uint       `db:"id"`
+	UserID    uint       `db:"user_id"`
+	GroupID   uint       `db:"group_id"`
+	StatusID  uint       `db:"status_id"`
+	Content   string     `db:"content"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `db:"edited_at"`
+	DeletedAt *time.Time `db:"deleted_at"`
+
+	ReplyToMessageID *uint `db:"reply_to_message_id"`
 }
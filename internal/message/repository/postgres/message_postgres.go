@@ -2,11 +2,42 @@ package postgres
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 
+	"github.com/lib/pq"
 	"github.com/lightlink/group-service/internal/message/domain/entity"
+	"github.com/lightlink/group-service/internal/message/repository"
+	outboxPostgres "github.com/lightlink/group-service/internal/outbox/repository/postgres"
 )
 
+const (
+	// Длина цитаты в превью ответа
+	replyPreviewLength = 200
+
+	foreignKeyViolation = "23503"
+)
+
+const selectMessages = `
+        SELECT m.id, m.user_id, m.group_id, ms.name, m.content, m.created_at, m.edited_at, m.deleted_at,
+            m.change_seq, m.content_version, m.reply_to_message_id, rm.user_id, LEFT(rm.content, $1), rm.deleted_at IS NOT NULL
+        FROM messages m
+        JOIN message_statuses ms ON m.status_id = ms.id
+        LEFT JOIN messages rm ON m.reply_to_message_id = rm.id`
+
+// withNextChangeSeq prefixes an UPDATE of message $1, which then sets
+// change_seq = (SELECT change_seq FROM seq). See nextChangeSeq.
+const withNextChangeSeq = `
+        WITH seq AS (
+            UPDATE groups SET change_seq = change_seq + 1
+            WHERE id = (SELECT group_id FROM messages WHERE id = $1)
+            RETURNING change_seq
+        )`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
 type MessagePostgresRepository struct {
 	DB *sql.DB
 }
@@ -17,20 +48,29 @@ func NewMessagePostgresRepository(db *sql.DB) *MessagePostgresRepository {
 	}
 }
 
-func (repo *MessagePostgresRepository) Create(messageEntity *entity.Message) (*entity.Message, error) {
+func (repo *MessagePostgresRepository) Create(messageEntity *entity.Message, outboxEvents repository.OutboxEventsFunc) (*entity.Message, error) {
 	tx, err := repo.DB.Begin()
 	if err != nil {
 		return nil, fmt.Errorf("failed to start transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	changeSeq, err := nextChangeSeq(tx, messageEntity.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
 	var messageID uint
 	err = tx.QueryRow(`
-        INSERT INTO messages (user_id, group_id, content, status_id)
-        VALUES ($1, $2, $3, (SELECT id FROM message_statuses WHERE name = 'pending'))
+        INSERT INTO messages (user_id, group_id, content, reply_to_message_id, status_id, change_seq)
+        VALUES ($1, $2, $3, $4, (SELECT id FROM message_statuses WHERE name = 'pending'), $5)
         RETURNING id`,
-		messageEntity.UserID, messageEntity.GroupID, messageEntity.Content,
+		messageEntity.UserID, messageEntity.GroupID, messageEntity.Content, messageEntity.ReplyToMessageID, changeSeq,
 	).Scan(&messageID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation && pqErr.Constraint == "fk_message_reply" {
+		return nil, entity.ErrInvalidReply
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert message: %w", err)
 	}
@@ -51,6 +91,10 @@ func (repo *MessagePostgresRepository) Create(messageEntity *entity.Message) (*e
 		}
 	}
 
+	if err = insertOutboxEvents(tx, messageID, messageEntity.Files, outboxEvents); err != nil {
+		return nil, err
+	}
+
 	if err = tx.Commit(); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -58,23 +102,98 @@ func (repo *MessagePostgresRepository) Create(messageEntity *entity.Message) (*e
 	return repo.getMessageWithFiles(messageID)
 }
 
-func (repo *MessagePostgresRepository) getMessageWithFiles(messageID uint) (*entity.Message, error) {
-	message := &entity.Message{}
+// insertOutboxEvents builds the events from the message as it is in tx, so they describe
+// the change being committed, and stores them in the same transaction.
+func insertOutboxEvents(tx *sql.Tx, messageID uint, files []entity.File, outboxEvents repository.OutboxEventsFunc) error {
+	if outboxEvents == nil {
+		return nil
+	}
 
-	err := repo.DB.QueryRow(`
-        SELECT m.id, m.user_id, m.group_id, ms.name, m.content, m.created_at
-        FROM messages m
-        JOIN message_statuses ms ON m.status_id = ms.id
-        WHERE m.id = $1`,
-		messageID,
-	).Scan(
+	storedMessage, err := scanMessage(tx.QueryRow(
+		selectMessages+`
+        WHERE m.id = $2`,
+		replyPreviewLength, messageID,
+	))
+	if err != nil {
+		return fmt.Errorf("failed to read changed message: %w", err)
+	}
+	storedMessage.Files = files
+
+	events, err := outboxEvents(&storedMessage)
+	if err != nil {
+		return fmt.Errorf("failed to build outbox events: %w", err)
+	}
+
+	return outboxPostgres.Insert(tx, events)
+}
+
+// nextChangeSeq takes the group's next change number. The group row stays locked until the
+// transaction ends, so changes of one group commit in change_seq order.
+func nextChangeSeq(tx *sql.Tx, groupID uint) (int64, error) {
+	var changeSeq int64
+	err := tx.QueryRow(`
+        UPDATE groups SET change_seq = change_seq + 1
+        WHERE id = $1
+        RETURNING change_seq`,
+		groupID,
+	).Scan(&changeSeq)
+	if err != nil {
+		return 0, fmt.Errorf("failed to take change seq: %w", err)
+	}
+
+	return changeSeq, nil
+}
+
+func scanMessage(row rowScanner) (entity.Message, error) {
+	// Reactions are loaded separately; a message without any still lists them as [].
+	message := entity.Message{Reactions: []entity.Reaction{}}
+	var replyToID, replyToUserID sql.NullInt64
+	var replyToContent sql.NullString
+	var replyToDeleted bool
+
+	err := row.Scan(
 		&message.ID,
 		&message.UserID,
 		&message.GroupID,
 		&message.Status,
 		&message.Content,
 		&message.CreatedAt,
+		&message.EditedAt,
+		&message.DeletedAt,
+		&message.ChangeSeq,
+		&message.ContentVersion,
+		&replyToID,
+		&replyToUserID,
+		&replyToContent,
+		&replyToDeleted,
 	)
+	if err != nil {
+		return message, err
+	}
+
+	if replyToID.Valid {
+		id := uint(replyToID.Int64)
+		message.ReplyToMessageID = &id
+		message.ReplyTo = &entity.MessagePreview{
+			ID:        id,
+			UserID:    uint(replyToUserID.Int64),
+			Content:   replyToContent.String,
+			IsDeleted: replyToDeleted,
+		}
+	}
+
+	return message, nil
+}
+
+func (repo *MessagePostgresRepository) getMessageWithFiles(messageID uint) (*entity.Message, error) {
+	message, err := scanMessage(repo.DB.QueryRow(
+		selectMessages+`
+        WHERE m.id = $2`,
+		replyPreviewLength, messageID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, entity.ErrMessageNotFound
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get message: %w", err)
 	}
@@ -86,7 +205,13 @@ func (repo *MessagePostgresRepository) getMessageWithFiles(messageID uint) (*ent
 	}
 	message.Files = files
 
-	return message, nil
+	reactions, err := repo.GetReactions(messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reactions: %w", err)
+	}
+	message.Reactions = reactions
+
+	return &message, nil
 }
 
 func (repo *MessagePostgresRepository) getFilesByMessageID(messageID uint) ([]entity.File, error) {
@@ -120,69 +245,439 @@ func (repo *MessagePostgresRepository) getFilesByMessageID(messageID uint) ([]en
 	return files, nil
 }
 
-func (repo *MessagePostgresRepository) getMessagesByGroupID(groupID uint) ([]entity.Message, error) {
+func (repo *MessagePostgresRepository) getFilesByMessageIDs(messageIDs []uint) (map[uint][]entity.File, error) {
+	ids := make([]int64, 0, len(messageIDs))
+	for _, id := range messageIDs {
+		ids = append(ids, int64(id))
+	}
+
 	rows, err := repo.DB.Query(`
-        SELECT m.id, m.user_id, m.group_id, ms.name, m.content, m.created_at
-        FROM messages m
-        JOIN message_statuses ms ON m.status_id = ms.id
-        WHERE m.group_id = $1
-        ORDER BY m.created_at ASC`,
-		groupID,
+        SELECT message_id, id, object_name, original_name, content_type, size, url
+        FROM files
+        WHERE message_id = ANY($1)
+        ORDER BY id`,
+		pq.Array(ids),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query messages: %w", err)
+		return nil, fmt.Errorf("failed to query files: %w", err)
 	}
 	defer rows.Close()
 
-	var messages []entity.Message
+	files := make(map[uint][]entity.File, len(messageIDs))
 	for rows.Next() {
-		var msg entity.Message
+		var messageID uint
+		var f entity.File
 		if err := rows.Scan(
-			&msg.ID,
-			&msg.UserID,
-			&msg.GroupID,
-			&msg.Status,
-			&msg.Content,
-			&msg.CreatedAt,
+			&messageID,
+			&f.ID,
+			&f.ObjectName,
+			&f.OriginalName,
+			&f.ContentType,
+			&f.Size,
+			&f.URL,
 		); err != nil {
+			return nil, fmt.Errorf("failed to scan file: %w", err)
+		}
+		files[messageID] = append(files[messageID], f)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over files: %w", err)
+	}
+
+	return files, nil
+}
+
+func (repo *MessagePostgresRepository) getMessagesByGroupID(groupID uint, cursor entity.MessageCursor) ([]entity.Message, error) {
+	order := "DESC"
+	if cursor.Forward() {
+		order = "ASC"
+	}
+
+	// One extra row tells the caller whether another page exists.
+	rows, err := repo.DB.Query(
+		selectMessages+`
+        WHERE m.group_id = $2
+            AND ($3 = 0 OR m.id < $3)
+            AND m.id > $4
+        ORDER BY m.id `+order+`
+        LIMIT $5`,
+		replyPreviewLength, groupID, cursor.BeforeID, cursor.AfterID, cursor.Limit+1,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query messages: %w", err)
+	}
+	defer rows.Close()
+
+	return scanMessages(rows)
+}
+
+func scanMessages(rows *sql.Rows) ([]entity.Message, error) {
+	var messages []entity.Message
+	for rows.Next() {
+		msg, err := scanMessage(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan message: %w", err)
 		}
 		messages = append(messages, msg)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over messages: %w", err)
+	}
+
 	return messages, nil
 }
 
-func (repo *MessagePostgresRepository) GetByGroupID(groupID uint) ([]entity.Message, error) {
+func (repo *MessagePostgresRepository) GetByGroupID(groupID uint, cursor entity.MessageCursor) ([]entity.Message, bool, error) {
 	// Получаем основные данные сообщений
-	messages, err := repo.getMessagesByGroupID(groupID)
+	messages, err := repo.getMessagesByGroupID(groupID, cursor)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get messages: %w", err)
+		return nil, false, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	hasMore := len(messages) > cursor.Limit
+	if hasMore {
+		messages = messages[:cursor.Limit]
+	}
+
+	if !cursor.Forward() {
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
+		}
+	}
+
+	// Получаем файлы и реакции всей страницы одним запросом
+	if err := repo.attachFiles(messages); err != nil {
+		return nil, false, err
+	}
+
+	return messages, hasMore, nil
+}
+
+func (repo *MessagePostgresRepository) GetChanges(groupID uint, afterChangeSeq int64, limit int) ([]entity.Message, bool, error) {
+	// One extra row tells the caller whether another page exists.
+	rows, err := repo.DB.Query(
+		selectMessages+`
+        WHERE m.group_id = $2 AND m.change_seq > $3
+        ORDER BY m.change_seq ASC
+        LIMIT $4`,
+		replyPreviewLength, groupID, afterChangeSeq, limit+1,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to query changed messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages, err := scanMessages(rows)
+	if err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+
+	if err := repo.attachFiles(messages); err != nil {
+		return nil, false, err
+	}
+
+	return messages, hasMore, nil
+}
+
+func (repo *MessagePostgresRepository) attachFiles(messages []entity.Message) error {
+	messageIDs := make([]uint, 0, len(messages))
+	for _, msg := range messages {
+		messageIDs = append(messageIDs, msg.ID)
+	}
+
+	files, err := repo.getFilesByMessageIDs(messageIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get files: %w", err)
+	}
+
+	reactions, err := repo.getReactionsByMessageIDs(messageIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get reactions: %w", err)
 	}
 
-	// Для каждого сообщения получаем файлы
 	for i := range messages {
-		files, err := repo.getFilesByMessageID(messages[i].ID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get files for message %d: %w", messages[i].ID, err)
+		messages[i].Files = files[messages[i].ID]
+		if messageReactions, ok := reactions[messages[i].ID]; ok {
+			messages[i].Reactions = messageReactions
 		}
-		messages[i].Files = files
+	}
+
+	return nil
+}
+
+func (repo *MessagePostgresRepository) GetReplies(rootMessageID uint) ([]entity.Message, error) {
+	rows, err := repo.DB.Query(`
+        WITH RECURSIVE thread AS (
+            SELECT id FROM messages WHERE reply_to_message_id = $2
+            UNION ALL
+            SELECT r.id FROM messages r JOIN thread t ON r.reply_to_message_id = t.id
+        )`+selectMessages+`
+        WHERE m.id IN (SELECT id FROM thread)
+        ORDER BY m.id ASC`,
+		replyPreviewLength, rootMessageID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query thread: %w", err)
+	}
+	defer rows.Close()
+
+	messages, err := scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := repo.attachFiles(messages); err != nil {
+		return nil, err
 	}
 
 	return messages, nil
 }
 
-func (repo *MessagePostgresRepository) UpdateStatus(messageID uint, statusName string) error {
-	_, err := repo.DB.Exec(`
+func (repo *MessagePostgresRepository) GetByID(messageID uint) (*entity.Message, error) {
+	return repo.getMessageWithFiles(messageID)
+}
+
+func (repo *MessagePostgresRepository) UpdateContent(messageID uint, content string, outboxEvents repository.OutboxEventsFunc) (*entity.Message, error) {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(withNextChangeSeq+`
+		UPDATE messages
+		SET content = $2,
+			edited_at = NOW(),
+			status_id = (SELECT id FROM message_statuses WHERE name = 'pending'),
+			content_version = content_version + 1,
+			change_seq = (SELECT change_seq FROM seq)
+		WHERE id = $1 AND deleted_at IS NULL`,
+		messageID, content,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update message content: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return nil, entity.ErrMessageNotFound
+	}
+
+	if err = insertOutboxEvents(tx, messageID, nil, outboxEvents); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return repo.getMessageWithFiles(messageID)
+}
+
+func (repo *MessagePostgresRepository) Delete(messageID uint, outboxEvents repository.OutboxEventsFunc) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Сообщение остаётся в истории как tombstone без содержимого
+	result, err := tx.Exec(withNextChangeSeq+`
+		UPDATE messages
+		SET content = '', deleted_at = NOW(), change_seq = (SELECT change_seq FROM seq)
+		WHERE id = $1 AND deleted_at IS NULL`,
+		messageID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete message: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return entity.ErrMessageNotFound
+	}
+
+	if _, err = tx.Exec("DELETE FROM files WHERE message_id = $1", messageID); err != nil {
+		return fmt.Errorf("failed to delete message files: %w", err)
+	}
+
+	if err = insertOutboxEvents(tx, messageID, nil, outboxEvents); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateStatus only applies to the given content version; for an edited or deleted
+// message it returns ErrMessageNotFound.
+func (repo *MessagePostgresRepository) UpdateStatus(messageID uint, statusName string, contentVersion int, outboxEvents repository.OutboxEventsFunc) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(withNextChangeSeq+`
 		UPDATE messages 
-		SET status_id = (SELECT id FROM message_statuses WHERE name = $1)
-		WHERE id = $2
-	`, statusName, messageID)
+		SET status_id = (SELECT id FROM message_statuses WHERE name = $2),
+			change_seq = (SELECT change_seq FROM seq)
+		WHERE id = $1 AND content_version = $3 AND deleted_at IS NULL
+	`, messageID, statusName, contentVersion)
 
 	if err != nil {
 		fmt.Printf("Failed to update message status: %v\n", err)
 		return err
 	}
 
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return entity.ErrMessageNotFound
+	}
+
+	if err = insertOutboxEvents(tx, messageID, nil, outboxEvents); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *MessagePostgresRepository) AddReaction(messageID, userID uint, emoji string) error {
+	return repo.changeReactions(messageID, `
+		INSERT INTO message_reactions (message_id, user_id, emoji)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (message_id, user_id, emoji) DO NOTHING`,
+		messageID, userID, emoji,
+	)
+}
+
+func (repo *MessagePostgresRepository) RemoveReaction(messageID, userID uint, emoji string) error {
+	return repo.changeReactions(messageID, `
+		DELETE FROM message_reactions
+		WHERE message_id = $1 AND user_id = $2 AND emoji = $3`,
+		messageID, userID, emoji,
+	)
+}
+
+// changeReactions runs the reaction query and, if it changed anything, moves the message
+// forward in the sync stream, since synced messages carry their reactions.
+func (repo *MessagePostgresRepository) changeReactions(messageID uint, query string, args ...interface{}) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to change reaction: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return nil
+	}
+
+	_, err = tx.Exec(withNextChangeSeq+`
+		UPDATE messages
+		SET change_seq = (SELECT change_seq FROM seq)
+		WHERE id = $1`,
+		messageID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update message change seq: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
+
+func (repo *MessagePostgresRepository) GetReactions(messageID uint) ([]entity.Reaction, error) {
+	reactions, err := repo.getReactionsByMessageIDs([]uint{messageID})
+	if err != nil {
+		return nil, err
+	}
+
+	if reactions[messageID] == nil {
+		return []entity.Reaction{}, nil
+	}
+
+	return reactions[messageID], nil
+}
+
+func (repo *MessagePostgresRepository) getReactionsByMessageIDs(messageIDs []uint) (map[uint][]entity.Reaction, error) {
+	ids := make([]int64, 0, len(messageIDs))
+	for _, id := range messageIDs {
+		ids = append(ids, int64(id))
+	}
+
+	rows, err := repo.DB.Query(`
+        SELECT message_id, emoji, COUNT(*), array_agg(user_id ORDER BY created_at)
+        FROM message_reactions
+        WHERE message_id = ANY($1)
+        GROUP BY message_id, emoji
+        ORDER BY message_id, MIN(created_at)`,
+		pq.Array(ids),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reactions: %w", err)
+	}
+	defer rows.Close()
+
+	reactions := make(map[uint][]entity.Reaction, len(messageIDs))
+	for rows.Next() {
+		var messageID uint
+		var userIDs []int64
+		var reaction entity.Reaction
+		if err := rows.Scan(
+			&messageID,
+			&reaction.Emoji,
+			&reaction.Count,
+			pq.Array(&userIDs),
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan reaction: %w", err)
+		}
+
+		reaction.UserIDs = make([]uint, 0, len(userIDs))
+		for _, userID := range userIDs {
+			reaction.UserIDs = append(reaction.UserIDs, uint(userID))
+		}
+
+		reactions[messageID] = append(reactions[messageID], reaction)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over reactions: %w", err)
+	}
+
+	return reactions, nil
+}
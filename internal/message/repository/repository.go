@@ -2,10 +2,23 @@ package repository
 
 import (
 	"github.com/lightlink/group-service/internal/message/domain/entity"
+	outboxEntity "github.com/lightlink/group-service/internal/outbox/domain/entity"
 )
 
+// OutboxEventsFunc builds the side effects of a message change. It is called inside the
+// change's transaction with the stored message, so the events are committed atomically with it.
+type OutboxEventsFunc func(message *entity.Message) ([]outboxEntity.Event, error)
+
 type MessageRepositoryI interface {
-	Create(messageEntity *entity.Message) (*entity.Message, error)
-	GetByGroupID(groupID uint) ([]entity.Message, error)
-	UpdateStatus(messageID uint, statusName string) error
+	Create(messageEntity *entity.Message, outboxEvents OutboxEventsFunc) (*entity.Message, error)
+	GetByGroupID(groupID uint, cursor entity.MessageCursor) (messages []entity.Message, hasMore bool, err error)
+	GetChanges(groupID uint, afterChangeSeq int64, limit int) (messages []entity.Message, hasMore bool, err error)
+	GetByID(messageID uint) (*entity.Message, error)
+	GetReplies(rootMessageID uint) ([]entity.Message, error)
+	UpdateContent(messageID uint, content string, outboxEvents OutboxEventsFunc) (*entity.Message, error)
+	Delete(messageID uint, outboxEvents OutboxEventsFunc) error
+	UpdateStatus(messageID uint, statusName string, contentVersion int, outboxEvents OutboxEventsFunc) error
+	AddReaction(messageID, userID uint, emoji string) error
+	RemoveReaction(messageID, userID uint, emoji string) error
+	GetReactions(messageID uint) ([]entity.Reaction, error)
 }
@@ -0,0 +1,88 @@
+package usecase
+
+const (
+	zeroWidthJoiner   = 0x200D
+	combiningKeycap   = 0x20E3
+	emojiPresentation = 0xFE0F
+	textPresentation  = 0xFE0E
+	skinToneFirst     = 0x1F3FB
+	skinToneLast      = 0x1F3FF
+	regionalFirst     = 0x1F1E6
+	regionalLast      = 0x1F1FF
+	tagFirst          = 0xE0020
+	tagLast           = 0xE007F
+)
+
+// emojiRanges are the blocks emoji bases come from. Some of them also hold plain symbols,
+// which is fine: the point is to reject text, not to mirror the Unicode emoji list exactly.
+var emojiRanges = [][2]rune{
+	{0x00A9, 0x00A9}, {0x00AE, 0x00AE},
+	{0x203C, 0x203C}, {0x2049, 0x2049},
+	{0x2100, 0x21FF}, // letterlike symbols, arrows
+	{0x2300, 0x23FF}, // misc technical
+	{0x24C2, 0x24C2},
+	{0x25A0, 0x27BF}, // geometric shapes, misc symbols, dingbats
+	{0x2934, 0x2935},
+	{0x2B00, 0x2BFF},
+	{0x3030, 0x3030}, {0x303D, 0x303D}, {0x3297, 0x3297}, {0x3299, 0x3299},
+	{0x1F000, 0x1FAFF},
+}
+
+// isEmoji reports whether s is a single emoji: a base optionally followed by a presentation
+// selector, a skin tone or tags, possibly joined with more of those by ZWJ; or a flag or a keycap.
+func isEmoji(s string) bool {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return false
+	}
+
+	switch first := runes[0]; {
+	case isRegionalIndicator(first):
+		return len(runes) == 2 && isRegionalIndicator(runes[1])
+	case first == '#' || first == '*' || (first >= '0' && first <= '9'):
+		rest := runes[1:]
+		return (len(rest) == 1 && rest[0] == combiningKeycap) ||
+			(len(rest) == 2 && rest[0] == emojiPresentation && rest[1] == combiningKeycap)
+	}
+
+	expectBase := true
+	for _, r := range runes {
+		if expectBase {
+			if !isEmojiBase(r) {
+				return false
+			}
+			expectBase = false
+			continue
+		}
+
+		switch {
+		case r == zeroWidthJoiner:
+			expectBase = true
+		case r == emojiPresentation || r == textPresentation:
+		case r >= skinToneFirst && r <= skinToneLast:
+		case r >= tagFirst && r <= tagLast:
+		default:
+			return false
+		}
+	}
+
+	return !expectBase
+}
+
+func isEmojiBase(r rune) bool {
+	if r >= skinToneFirst && r <= skinToneLast || isRegionalIndicator(r) {
+		return false
+	}
+
+	for _, emojiRange := range emojiRanges {
+		if r >= emojiRange[0] && r <= emojiRange[1] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= regionalFirst && r <= regionalLast
+}
@@ -0,0 +1,41 @@
+package usecase
+
+import "testing"
+
+func TestIsEmoji(t *testing.T) {
+	tests := []struct {
+		name  string
+		emoji string
+		want  bool
+	}{
+		{name: "single emoji", emoji: "👍", want: true},
+		{name: "emoji presentation selector", emoji: "❤️", want: true},
+		{name: "skin tone", emoji: "👍🏽", want: true},
+		{name: "zwj sequence", emoji: "👨‍👩‍👧", want: true},
+		{name: "zwj sequence with selector", emoji: "🏳️‍🌈", want: true},
+		{name: "flag", emoji: "🇺🇸", want: true},
+		{name: "subdivision flag", emoji: "🏴󠁧󠁢󠁳󠁣󠁴󠁿", want: true},
+		{name: "keycap", emoji: "1️⃣", want: true},
+		{name: "keycap without selector", emoji: "#⃣", want: true},
+		{name: "symbol", emoji: "⭐", want: true},
+
+		{name: "empty", emoji: "", want: false},
+		{name: "letter", emoji: "a", want: false},
+		{name: "word", emoji: "hello", want: false},
+		{name: "emoji followed by text", emoji: "👍a", want: false},
+		{name: "space before emoji", emoji: " 👍", want: false},
+		{name: "two emoji", emoji: "👍👍", want: false},
+		{name: "half a flag", emoji: "🇺", want: false},
+		{name: "digit without keycap", emoji: "1", want: false},
+		{name: "trailing joiner", emoji: "👍‍", want: false},
+		{name: "lone skin tone", emoji: "🏽", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isEmoji(tt.emoji); got != tt.want {
+				t.Errorf("isEmoji(%q) = %v, want %v", tt.emoji, got, tt.want)
+			}
+		})
+	}
+}
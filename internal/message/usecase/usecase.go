@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
@@ -8,94 +9,192 @@ import (
 
 	"github.com/lightlink/group-service/infrastructure/ws"
 	fileRepo "github.com/lightlink/group-service/internal/file/repository"
+	groupEntity "github.com/lightlink/group-service/internal/group/domain/entity"
+	"github.com/lightlink/group-service/internal/group/permission"
 	groupRepo "github.com/lightlink/group-service/internal/group/repository"
 	messageDTO "github.com/lightlink/group-service/internal/message/domain/dto"
 	"github.com/lightlink/group-service/internal/message/domain/entity"
 	messageRepo "github.com/lightlink/group-service/internal/message/repository"
 	notificationDTO "github.com/lightlink/group-service/internal/notification/domain/dto"
-	notificationRepo "github.com/lightlink/group-service/internal/notification/repository"
+	outboxEntity "github.com/lightlink/group-service/internal/outbox/domain/entity"
+	"github.com/lightlink/group-service/internal/outbox/relay"
 )
 
 const (
 	HATE_MESSAGE_STATUS    = "hate"
 	NEUTRAL_MESSAGE_STATUS = "neutral"
+
+	DEFAULT_PAGE_LIMIT = 50
+	MAX_PAGE_LIMIT     = 100
+
+	MAX_EMOJI_LENGTH = 64
 )
 
 type MessageUsecaseI interface {
 	Create(createRequest *messageDTO.CreateMessageRequest) (*entity.Message, error)
-	GetByGroupID(groupID uint) ([]entity.Message, error)
+	GetByGroupID(userID, groupID uint, cursor entity.MessageCursor) (*entity.MessagePage, error)
+	GetByID(userID, messageID uint) (*entity.Message, error)
+	Sync(userID, groupID uint, afterChangeSeq int64, limit int) (*entity.MessageChanges, error)
+	GetThread(userID, rootMessageID uint) (*entity.Thread, error)
+	Update(userID, messageID uint, content string) (*entity.Message, error)
+	Delete(userID, messageID uint) error
+	AddReaction(userID, messageID uint, emoji string) ([]entity.Reaction, error)
+	RemoveReaction(userID, messageID uint, emoji string) ([]entity.Reaction, error)
 	UpdateHateSpeechLabel(hateSpeechResponse messageDTO.MessageHateSpeechResponse)
 }
 
 type MessageUsecase struct {
-	messageRepo           messageRepo.MessageRepositoryI
-	groupRepo             groupRepo.GroupRepositoryI
-	fileRepo              fileRepo.FileRepositoryI
-	notificationRepo      notificationRepo.NotificationRepositoryI
-	messageHateSpeechRepo messageRepo.MessageHateSpeechRepositoryI
-	messagingServer       ws.MessagingServer
+	messageRepo       messageRepo.MessageRepositoryI
+	groupRepo         groupRepo.GroupRepositoryI
+	fileRepo          fileRepo.FileRepositoryI
+	messagingServer   ws.MessagingServer
+	streamPositions   ws.StreamPositionProvider
+	outboxNotifier    relay.Notifier
+	permissionChecker *permission.Checker
 }
 
 func NewMessageUsecase(
 	messageRepo messageRepo.MessageRepositoryI,
-	notificationRepo notificationRepo.NotificationRepositoryI,
 	groupRepo groupRepo.GroupRepositoryI,
 	fileRepo fileRepo.FileRepositoryI,
-	messageHateSpeechRepo messageRepo.MessageHateSpeechRepositoryI,
 	messagingServer ws.MessagingServer,
+	streamPositions ws.StreamPositionProvider,
+	outboxNotifier relay.Notifier,
 ) *MessageUsecase {
 	return &MessageUsecase{
-		messageRepo:           messageRepo,
-		notificationRepo:      notificationRepo,
-		groupRepo:             groupRepo,
-		fileRepo:              fileRepo,
-		messageHateSpeechRepo: messageHateSpeechRepo,
-		messagingServer:       messagingServer,
+		messageRepo:       messageRepo,
+		groupRepo:         groupRepo,
+		fileRepo:          fileRepo,
+		messagingServer:   messagingServer,
+		streamPositions:   streamPositions,
+		outboxNotifier:    outboxNotifier,
+		permissionChecker: permission.NewChecker(groupRepo),
 	}
 }
 
-func (uc *MessageUsecase) initiateHateSpeechCheck(messageID, groupID uint, content string) {
-	hateSpeechRequest := messageDTO.MessageHateSpeechRequest{
-		ID:      messageID,
-		GroupID: groupID,
-		Content: content,
-	}
-
-	err := uc.messageHateSpeechRepo.Send(hateSpeechRequest)
-	if err != nil {
-		log.Printf("ERR: An error occured sending message in hate-speech-service: %v\n", err)
-	}
-}
-
-func (uc *MessageUsecase) sendIncomingMessageNotification(senderID, roomID uint, content string) {
-	receiverIDs, err := uc.groupRepo.GetMemberIDsByGroupID(roomID)
-	if err != nil {
-		log.Printf("ERR: An error occured due selecting group members: %v\n", err)
-	}
+// messageCreatedEvents are the side effects of a new message; they are written to the
+// outbox together with it and delivered by the relay.
+func (uc *MessageUsecase) messageCreatedEvents(message *entity.Message, receiverIDs []uint) ([]outboxEntity.Event, error) {
+	events := make([]outboxEntity.Event, 0, len(receiverIDs)+2)
 
 	for _, receiverID := range receiverIDs {
-		if receiverID == senderID {
+		if receiverID == message.UserID {
 			continue
 		}
 
-		uc.notificationRepo.Send(notificationDTO.RawNotification{
+		event, err := outboxEntity.NewNotificationEvent(notificationDTO.RawNotification{
 			Type: "incomingMessage",
 			Payload: map[string]interface{}{
-				"from_user_id": strconv.FormatUint(uint64(senderID), 10),
+				"from_user_id": strconv.FormatUint(uint64(message.UserID), 10),
 				"to_user_id":   strconv.FormatUint(uint64(receiverID), 10),
-				"room_id":      strconv.FormatUint(uint64(roomID), 10),
-				"content":      content,
+				"room_id":      strconv.FormatUint(uint64(message.GroupID), 10),
+				"content":      message.Content,
 			},
 		})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	event, err := hateSpeechRequestEvent(message)
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, event)
+
+	event, err = outboxEntity.NewGroupPublishEvent(message.GroupID, messageDTO.MessageSignal{
+		Type:    "newMessage",
+		Payload: messageDTO.MessageEntityToIncomingPayload(*message),
+	})
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, event)
+
+	return events, nil
+}
+
+// messageEditedEvents send the new content version to moderation and announce the edit.
+func messageEditedEvents(message *entity.Message) ([]outboxEntity.Event, error) {
+	hateSpeechEvent, err := hateSpeechRequestEvent(message)
+	if err != nil {
+		return nil, err
+	}
+
+	publishEvent, err := outboxEntity.NewGroupPublishEvent(message.GroupID, messageDTO.MessageSignal{
+		Type: "messageEdited",
+		Payload: messageDTO.MessageEditedPayload{
+			ID:       message.ID,
+			GroupID:  message.GroupID,
+			Status:   message.Status,
+			Content:  message.Content,
+			EditedAt: *message.EditedAt,
+		},
+	})
+	if err != nil {
+		return nil, err
 	}
+
+	return []outboxEntity.Event{hateSpeechEvent, publishEvent}, nil
+}
+
+func messageDeletedEvents(message *entity.Message, deletedBy uint) ([]outboxEntity.Event, error) {
+	event, err := outboxEntity.NewGroupPublishEvent(message.GroupID, messageDTO.MessageSignal{
+		Type: "messageDeleted",
+		Payload: messageDTO.MessageDeletedPayload{
+			MessageID: message.ID,
+			GroupID:   message.GroupID,
+			DeletedBy: deletedBy,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []outboxEntity.Event{event}, nil
+}
+
+func hateSpeechRequestEvent(message *entity.Message) (outboxEntity.Event, error) {
+	return outboxEntity.NewHateSpeechRequestEvent(messageDTO.MessageHateSpeechRequest{
+		ID:             message.ID,
+		GroupID:        message.GroupID,
+		Content:        message.Content,
+		ContentVersion: message.ContentVersion,
+	})
 }
 
 func (uc *MessageUsecase) Create(createRequest *messageDTO.CreateMessageRequest) (*entity.Message, error) {
+	requiredActions := []permission.Action{permission.SendMessage}
+	if len(createRequest.Files) > 0 {
+		requiredActions = append(requiredActions, permission.AttachFiles)
+	}
+
+	err := uc.permissionChecker.Check(createRequest.GroupID, createRequest.UserID, requiredActions...)
+	if err != nil {
+		return nil, err
+	}
+
+	if createRequest.ReplyToMessageID != nil {
+		parent, err := uc.messageRepo.GetByID(*createRequest.ReplyToMessageID)
+		if errors.Is(err, entity.ErrMessageNotFound) {
+			return nil, entity.ErrInvalidReply
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if parent.GroupID != createRequest.GroupID {
+			return nil, entity.ErrInvalidReply
+		}
+	}
+
 	messageEntity := entity.Message{
-		UserID:  createRequest.UserID,
-		GroupID: createRequest.GroupID,
-		Content: createRequest.Content,
-		Files:   make([]entity.File, 0, len(createRequest.Files)),
+		UserID:           createRequest.UserID,
+		GroupID:          createRequest.GroupID,
+		Content:          createRequest.Content,
+		ReplyToMessageID: createRequest.ReplyToMessageID,
+		Files:            make([]entity.File, 0, len(createRequest.Files)),
 	}
 
 	for _, fileHeader := range createRequest.Files {
@@ -134,75 +233,316 @@ func (uc *MessageUsecase) Create(createRequest *messageDTO.CreateMessageRequest)
 		})
 	}
 
-	createdMessageEntity, err := uc.messageRepo.Create(&messageEntity)
+	receiverIDs, err := uc.groupRepo.GetMemberIDsByGroupID(createRequest.GroupID)
 	if err != nil {
 		return nil, err
 	}
 
-	filesWithURLs := make([]messageDTO.FileInfo, 0, len(createdMessageEntity.Files))
-	for _, file := range createdMessageEntity.Files {
-		url, err := uc.fileRepo.GetPresignedURL(file.ObjectName, 24*time.Hour)
-		if err == nil {
-			filesWithURLs = append(filesWithURLs, messageDTO.FileInfo{
-				Name: file.OriginalName,
-				URL:  url,
-				Type: file.ContentType,
-				Size: file.Size,
-			})
+	createdMessageEntity, err := uc.messageRepo.Create(&messageEntity, func(message *entity.Message) ([]outboxEntity.Event, error) {
+		return uc.messageCreatedEvents(message, receiverIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	uc.outboxNotifier.Notify()
+
+	return createdMessageEntity, nil
+}
+
+func (uc *MessageUsecase) GetByGroupID(userID, groupID uint, cursor entity.MessageCursor) (*entity.MessagePage, error) {
+	if err := cursor.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := uc.permissionChecker.RequireMember(groupID, userID); err != nil {
+		return nil, err
+	}
+
+	if cursor.Limit <= 0 {
+		cursor.Limit = DEFAULT_PAGE_LIMIT
+	}
+	if cursor.Limit > MAX_PAGE_LIMIT {
+		cursor.Limit = MAX_PAGE_LIMIT
+	}
+
+	messages, hasMore, err := uc.messageRepo.GetByGroupID(groupID, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range messages {
+		uc.presignFileURLs(messages[i].Files)
+	}
+
+	page := &entity.MessagePage{
+		Messages: messages,
+	}
+
+	if hasMore && len(messages) > 0 {
+		nextCursor := messages[0].ID
+		if cursor.Forward() {
+			nextCursor = messages[len(messages)-1].ID
 		}
+		page.NextCursor = &nextCursor
 	}
 
-	messagePayload := messageDTO.IncomingMessagePayload{
-		ID:      createdMessageEntity.ID,
-		UserID:  createdMessageEntity.UserID,
-		GroupID: createdMessageEntity.GroupID,
-		Status:  createdMessageEntity.Status,
-		Content: createRequest.Content,
-		Files:   filesWithURLs,
+	if page.Messages == nil {
+		page.Messages = []entity.Message{}
 	}
 
-	go uc.sendIncomingMessageNotification(
-		createdMessageEntity.UserID,
-		createdMessageEntity.GroupID,
-		createdMessageEntity.Content,
-	)
+	return page, nil
+}
 
-	go uc.initiateHateSpeechCheck(
-		createdMessageEntity.ID,
-		createdMessageEntity.GroupID,
-		createdMessageEntity.Content,
-	)
+func (uc *MessageUsecase) GetByID(userID, messageID uint) (*entity.Message, error) {
+	message, err := uc.messageRepo.GetByID(messageID)
+	if err != nil {
+		return nil, err
+	}
 
-	uc.messagingServer.PublishToGroup(
-		createdMessageEntity.GroupID,
-		messageDTO.MessageSignal{
-			Type:    "newMessage",
-			Payload: messagePayload,
-		},
-	)
+	if err := uc.permissionChecker.RequireMember(message.GroupID, userID); err != nil {
+		return nil, err
+	}
 
-	return createdMessageEntity, nil
+	uc.presignFileURLs(message.Files)
+
+	return message, nil
 }
 
-func (uc *MessageUsecase) GetByGroupID(groupID uint) ([]entity.Message, error) {
-	messages, err := uc.messageRepo.GetByGroupID(groupID)
+// Sync returns the group's message changes after afterChangeSeq, for clients that reconnect
+// and could not recover the missed publications from the realtime server's history.
+func (uc *MessageUsecase) Sync(userID, groupID uint, afterChangeSeq int64, limit int) (*entity.MessageChanges, error) {
+	if err := uc.permissionChecker.RequireMember(groupID, userID); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = DEFAULT_PAGE_LIMIT
+	}
+	if limit > MAX_PAGE_LIMIT {
+		limit = MAX_PAGE_LIMIT
+	}
+
+	changes := &entity.MessageChanges{
+		Messages:          []entity.Message{},
+		DeletedMessageIDs: []uint{},
+		Cursor:            afterChangeSeq,
+	}
+
+	// The position is taken first: a change published before it is already committed, so it is
+	// read below, and anything published after it can be recovered from the channel history.
+	channel := groupEntity.GroupChannel(strconv.FormatUint(uint64(groupID), 10))
+	position, err := uc.streamPositions.StreamPosition(channel)
+	if err != nil {
+		log.Printf("ERR: Failed to get stream position of %s: %v\n", channel, err)
+	} else {
+		changes.Stream = &entity.StreamPosition{
+			Offset: position.Offset,
+			Epoch:  position.Epoch,
+		}
+	}
+
+	messages, hasMore, err := uc.messageRepo.GetChanges(groupID, afterChangeSeq, limit)
 	if err != nil {
 		return nil, err
 	}
+	changes.HasMore = hasMore
 
-	for i := range messages {
-		for j := range messages[i].Files {
-			url, err := uc.fileRepo.GetPresignedURL(
-				messages[i].Files[j].ObjectName,
-				24*time.Hour,
-			)
-			if err == nil {
-				messages[i].Files[j].URL = url
-			}
+	for _, message := range messages {
+		changes.Cursor = message.ChangeSeq
+
+		if message.IsDeleted() {
+			changes.DeletedMessageIDs = append(changes.DeletedMessageIDs, message.ID)
+			continue
 		}
+
+		uc.presignFileURLs(message.Files)
+		changes.Messages = append(changes.Messages, message)
 	}
 
-	return messages, nil
+	return changes, nil
+}
+
+func (uc *MessageUsecase) GetThread(userID, rootMessageID uint) (*entity.Thread, error) {
+	root, err := uc.messageRepo.GetByID(rootMessageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.permissionChecker.RequireMember(root.GroupID, userID); err != nil {
+		return nil, err
+	}
+
+	replies, err := uc.messageRepo.GetReplies(rootMessageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if replies == nil {
+		replies = []entity.Message{}
+	}
+
+	uc.presignFileURLs(root.Files)
+	for i := range replies {
+		uc.presignFileURLs(replies[i].Files)
+	}
+
+	return &entity.Thread{
+		Root:    *root,
+		Replies: replies,
+	}, nil
+}
+
+func (uc *MessageUsecase) presignFileURLs(files []entity.File) {
+	for i := range files {
+		url, err := uc.fileRepo.GetPresignedURL(files[i].ObjectName, 24*time.Hour)
+		if err == nil {
+			files[i].URL = url
+		}
+	}
+}
+
+func (uc *MessageUsecase) Update(userID, messageID uint, content string) (*entity.Message, error) {
+	if content == "" {
+		return nil, entity.ErrEmptyContent
+	}
+
+	message, err := uc.messageRepo.GetByID(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.IsDeleted() {
+		return nil, entity.ErrMessageNotFound
+	}
+
+	if message.UserID != userID {
+		return nil, groupEntity.ErrPermissionDenied
+	}
+
+	err = uc.permissionChecker.Check(message.GroupID, userID, permission.SendMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	updatedMessage, err := uc.messageRepo.UpdateContent(messageID, content, messageEditedEvents)
+	if err != nil {
+		return nil, err
+	}
+	uc.outboxNotifier.Notify()
+
+	return updatedMessage, nil
+}
+
+func (uc *MessageUsecase) Delete(userID, messageID uint) error {
+	message, err := uc.messageRepo.GetByID(messageID)
+	if err != nil {
+		return err
+	}
+
+	if message.IsDeleted() {
+		return entity.ErrMessageNotFound
+	}
+
+	// Автор может удалить своё сообщение, модерация — любое
+	if message.UserID == userID {
+		err = uc.permissionChecker.RequireMember(message.GroupID, userID)
+	} else {
+		err = uc.permissionChecker.Check(message.GroupID, userID, permission.DeleteMessages)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = uc.messageRepo.Delete(messageID, func(message *entity.Message) ([]outboxEntity.Event, error) {
+		return messageDeletedEvents(message, userID)
+	})
+	if err != nil {
+		return err
+	}
+	uc.outboxNotifier.Notify()
+
+	for _, file := range message.Files {
+		if err := uc.fileRepo.DeleteObject(file.ObjectName); err != nil {
+			log.Printf("ERR: Failed to delete object %s of message %d: %v\n", file.ObjectName, message.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (uc *MessageUsecase) AddReaction(userID, messageID uint, emoji string) ([]entity.Reaction, error) {
+	message, err := uc.getReactableMessage(userID, messageID, emoji)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.messageRepo.AddReaction(messageID, userID, emoji); err != nil {
+		return nil, err
+	}
+
+	return uc.publishReactions(message)
+}
+
+func (uc *MessageUsecase) RemoveReaction(userID, messageID uint, emoji string) ([]entity.Reaction, error) {
+	message, err := uc.getReactableMessage(userID, messageID, emoji)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.messageRepo.RemoveReaction(messageID, userID, emoji); err != nil {
+		return nil, err
+	}
+
+	return uc.publishReactions(message)
+}
+
+func (uc *MessageUsecase) getReactableMessage(userID, messageID uint, emoji string) (*entity.Message, error) {
+	if len(emoji) > MAX_EMOJI_LENGTH || !isEmoji(emoji) {
+		return nil, entity.ErrInvalidEmoji
+	}
+
+	message, err := uc.messageRepo.GetByID(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.IsDeleted() {
+		return nil, entity.ErrMessageNotFound
+	}
+
+	if err := uc.permissionChecker.RequireMember(message.GroupID, userID); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+func (uc *MessageUsecase) publishReactions(message *entity.Message) ([]entity.Reaction, error) {
+	reactions, err := uc.messageRepo.GetReactions(message.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if reactions == nil {
+		reactions = []entity.Reaction{}
+	}
+
+	err = uc.messagingServer.PublishToGroup(
+		message.GroupID,
+		messageDTO.MessageSignal{
+			Type: "reactionUpdated",
+			Payload: messageDTO.ReactionUpdatedPayload{
+				MessageID: message.ID,
+				GroupID:   message.GroupID,
+				Reactions: reactions,
+			},
+		},
+	)
+	if err != nil {
+		log.Printf("ERR: Failed to publish reactionUpdated for message %d: %v\n", message.ID, err)
+	}
+
+	return reactions, nil
 }
 
 func (uc *MessageUsecase) UpdateHateSpeechLabel(hateSpeechResponse messageDTO.MessageHateSpeechResponse) {
@@ -213,25 +553,37 @@ func (uc *MessageUsecase) UpdateHateSpeechLabel(hateSpeechResponse messageDTO.Me
 		newStatus = NEUTRAL_MESSAGE_STATUS
 	}
 
-	err := uc.messageRepo.UpdateStatus(hateSpeechResponse.ID, newStatus)
+	// Only hate verdicts are announced; a neutral one changes nothing clients show.
+	var outboxEvents messageRepo.OutboxEventsFunc
+	if newStatus == HATE_MESSAGE_STATUS {
+		outboxEvents = hateUpdateEvents
+	}
+
+	err := uc.messageRepo.UpdateStatus(hateSpeechResponse.ID, newStatus, hateSpeechResponse.ContentVersion, outboxEvents)
+	if errors.Is(err, entity.ErrMessageNotFound) {
+		fmt.Printf("Ignoring stale hate speech verdict for message %d, version %d\n", hateSpeechResponse.ID, hateSpeechResponse.ContentVersion)
+		return
+	}
 	if err != nil {
 		fmt.Printf("Failed to update status for message %d: %v\n", hateSpeechResponse.ID, err)
 		return
 	}
 
-	if newStatus != HATE_MESSAGE_STATUS {
-		return
+	if outboxEvents != nil {
+		uc.outboxNotifier.Notify()
 	}
+}
 
-	hateMessagecknowledgementPayload := messageDTO.HateSpeechStatusAckPayload{
-		MessageID: hateSpeechResponse.ID,
-	}
-	fmt.Printf("Sending message hate status %d\n", hateSpeechResponse.ID)
-	go uc.messagingServer.PublishToGroup(
-		hateSpeechResponse.GroupID,
-		messageDTO.MessageSignal{
-			Type:    "hateUpdate",
-			Payload: hateMessagecknowledgementPayload,
+func hateUpdateEvents(message *entity.Message) ([]outboxEntity.Event, error) {
+	event, err := outboxEntity.NewGroupPublishEvent(message.GroupID, messageDTO.MessageSignal{
+		Type: "hateUpdate",
+		Payload: messageDTO.HateSpeechStatusAckPayload{
+			MessageID: message.ID,
 		},
-	)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []outboxEntity.Event{event}, nil
 }
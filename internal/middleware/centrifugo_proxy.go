@@ -0,0 +1,225 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/lightlink/group-service/internal/auth"
+	groupEntity "github.com/lightlink/group-service/internal/group/domain/entity"
+	groupRepo "github.com/lightlink/group-service/internal/group/repository"
+	presenceRepo "github.com/lightlink/group-service/internal/presence/repository"
+)
+
+const (
+	PROXY_SECRET_HEADER = "X-Centrifugo-Proxy-Secret"
+
+	// Centrifugo protocol codes, see https://centrifugal.dev/docs/server/proxy
+	PROXY_PERMISSION_DENIED_CODE = 103
+	PROXY_UNAUTHORIZED_CODE      = 3501
+
+	// Centrifugo has no disconnect proxy, so connections are given a short expiry and
+	// every refresh request marks the user as seen. Last-seen is accurate to this interval.
+	LAST_SEEN_REFRESH_INTERVAL = time.Minute
+)
+
+type proxyConnectRequest struct {
+	Client    string `json:"client"`
+	Transport string `json:"transport"`
+}
+
+type proxyRefreshRequest struct {
+	Client string `json:"client"`
+	User   string `json:"user"`
+}
+
+type proxySubscribeRequest struct {
+	Client  string `json:"client"`
+	User    string `json:"user"`
+	Channel string `json:"channel"`
+}
+
+type proxyError struct {
+	Code    uint32 `json:"code"`
+	Message string `json:"message"`
+}
+
+type proxyDisconnect struct {
+	Code   uint32 `json:"code"`
+	Reason string `json:"reason"`
+}
+
+type proxyResponse struct {
+	Result     interface{}      `json:"result,omitempty"`
+	Error      *proxyError      `json:"error,omitempty"`
+	Disconnect *proxyDisconnect `json:"disconnect,omitempty"`
+}
+
+// CentrifugoProxy answers Centrifugo's connect, refresh and subscribe proxy requests, so channel
+// access is decided by current group membership rather than by claims baked into tokens.
+type CentrifugoProxy struct {
+	groupRepo    groupRepo.GroupRepositoryI
+	lastSeenRepo presenceRepo.LastSeenRepositoryI
+	tokenKey     []byte
+	proxySecret  string
+}
+
+func NewCentrifugoProxy(groupRepo groupRepo.GroupRepositoryI, lastSeenRepo presenceRepo.LastSeenRepositoryI) *CentrifugoProxy {
+	return &CentrifugoProxy{
+		groupRepo:    groupRepo,
+		lastSeenRepo: lastSeenRepo,
+		tokenKey:     []byte(os.Getenv("CENTRIFUGO_TOKEN_HMAC_SECRET_KEY")),
+		proxySecret:  os.Getenv("CENTRIFUGO_PROXY_SECRET"),
+	}
+}
+
+// Connect authenticates the connection with the access_token Centrifugo forwards from the client.
+func (p *CentrifugoProxy) Connect(w http.ResponseWriter, r *http.Request) {
+	if !p.fromCentrifugo(w, r) {
+		return
+	}
+
+	var req proxyConnectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tokenString := accessTokenFromRequest(r)
+	if tokenString == "" {
+		writeProxyResponse(w, proxyResponse{
+			Disconnect: &proxyDisconnect{Code: PROXY_UNAUTHORIZED_CODE, Reason: "unauthorized"},
+		})
+		return
+	}
+
+	userIDString, err := auth.ParseAccessToken(tokenString, p.tokenKey)
+	if err != nil {
+		fmt.Println("Centrifugo connect rejected:", err)
+		writeProxyResponse(w, proxyResponse{
+			Disconnect: &proxyDisconnect{Code: PROXY_UNAUTHORIZED_CODE, Reason: "unauthorized"},
+		})
+		return
+	}
+
+	p.RecordActivity(userIDString)
+
+	writeProxyResponse(w, proxyResponse{
+		Result: map[string]interface{}{
+			"user":      userIDString,
+			"expire_at": time.Now().Add(LAST_SEEN_REFRESH_INTERVAL).Unix(),
+		},
+	})
+}
+
+// Refresh extends a live connection and records that its user is still online.
+func (p *CentrifugoProxy) Refresh(w http.ResponseWriter, r *http.Request) {
+	if !p.fromCentrifugo(w, r) {
+		return
+	}
+
+	var req proxyRefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	p.RecordActivity(req.User)
+
+	writeProxyResponse(w, proxyResponse{
+		Result: map[string]interface{}{
+			"expire_at": time.Now().Add(LAST_SEEN_REFRESH_INTERVAL).Unix(),
+		},
+	})
+}
+
+// RecordActivity updates the user's last-seen time. Failures are only logged: they must
+// not break the connection itself.
+func (p *CentrifugoProxy) RecordActivity(userIDString string) {
+	userID, err := strconv.ParseUint(userIDString, 10, 32)
+	if err != nil {
+		return
+	}
+
+	if err := p.lastSeenRepo.Touch(uint(userID)); err != nil {
+		log.Printf("ERR: Failed to record last seen for user %d: %v\n", userID, err)
+	}
+}
+
+// Subscribe allows a subscription only to channels of groups the user is a member of,
+// and to user channels only for their owner.
+func (p *CentrifugoProxy) Subscribe(w http.ResponseWriter, r *http.Request) {
+	if !p.fromCentrifugo(w, r) {
+		return
+	}
+
+	var req proxySubscribeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	allowed, err := p.CanSubscribe(req.User, req.Channel)
+	if err != nil {
+		fmt.Println("Centrifugo subscribe check failed:", err)
+		http.Error(w, "Failed to check membership", http.StatusInternalServerError)
+		return
+	}
+
+	if !allowed {
+		writeProxyResponse(w, proxyResponse{
+			Error: &proxyError{Code: PROXY_PERMISSION_DENIED_CODE, Message: "permission denied"},
+		})
+		return
+	}
+
+	writeProxyResponse(w, proxyResponse{Result: map[string]interface{}{}})
+}
+
+// CanSubscribe is also used by the embedded realtime server, so both backends apply the same rules.
+func (p *CentrifugoProxy) CanSubscribe(userIDString, channel string) (bool, error) {
+	groupIDString, channelUserID, ok := groupEntity.ParseChannel(channel)
+	if !ok {
+		return false, nil
+	}
+
+	if channelUserID != "" && channelUserID != userIDString {
+		return false, nil
+	}
+
+	userID, err := strconv.ParseUint(userIDString, 10, 32)
+	if err != nil {
+		return false, nil
+	}
+
+	groupID, err := strconv.ParseUint(groupIDString, 10, 32)
+	if err != nil {
+		return false, nil
+	}
+
+	return p.groupRepo.IsMember(uint(groupID), uint(userID))
+}
+
+// fromCentrifugo checks the shared secret Centrifugo sends as a static proxy header.
+// Without a configured secret every request is refused.
+func (p *CentrifugoProxy) fromCentrifugo(w http.ResponseWriter, r *http.Request) bool {
+	secret := r.Header.Get(PROXY_SECRET_HEADER)
+	if p.proxySecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(p.proxySecret)) != 1 {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
+func writeProxyResponse(w http.ResponseWriter, response proxyResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		fmt.Println("Failed to write proxy response")
+	}
+}
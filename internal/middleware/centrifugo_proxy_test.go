@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"testing"
+
+	groupEntity "github.com/lightlink/group-service/internal/group/domain/entity"
+	groupRepo "github.com/lightlink/group-service/internal/group/repository"
+)
+
+// memberRepo only answers IsMember; any other call panics on the nil interface.
+type memberRepo struct {
+	groupRepo.GroupRepositoryI
+	members map[uint][]uint
+}
+
+func (r memberRepo) IsMember(groupID uint, userID uint) (bool, error) {
+	for _, memberID := range r.members[groupID] {
+		if memberID == userID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func TestCanSubscribe(t *testing.T) {
+	proxy := NewCentrifugoProxy(memberRepo{members: map[uint][]uint{7: {1, 2}}}, nil)
+
+	tests := []struct {
+		name    string
+		userID  string
+		channel string
+		want    bool
+	}{
+		{name: "member joins the room", userID: "1", channel: groupEntity.RoomChannel("7"), want: true},
+		{name: "member reads group messages", userID: "1", channel: groupEntity.GroupChannel("7"), want: true},
+		{name: "member opens own user channel", userID: "1", channel: groupEntity.UserChannel("7", "1"), want: true},
+		{name: "member opens another member's user channel", userID: "1", channel: groupEntity.UserChannel("7", "2"), want: false},
+		{name: "outsider reads group messages", userID: "3", channel: groupEntity.GroupChannel("7"), want: false},
+		{name: "outsider opens own user channel", userID: "3", channel: groupEntity.UserChannel("7", "3"), want: false},
+		{name: "unknown channel", userID: "1", channel: "personal:7", want: false},
+		{name: "non-numeric group id", userID: "1", channel: groupEntity.GroupChannel("abc"), want: false},
+		{name: "non-numeric user id", userID: "abc", channel: groupEntity.GroupChannel("7"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := proxy.CanSubscribe(tt.userID, tt.channel)
+			if err != nil {
+				t.Fatalf("CanSubscribe() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CanSubscribe(%q, %q) = %v, want %v", tt.userID, tt.channel, got, tt.want)
+			}
+		})
+	}
+}
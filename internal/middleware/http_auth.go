@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/lightlink/group-service/internal/auth"
+)
+
+const USER_ID_HEADER = "X-User-ID"
+
+// AuthHTTP authenticates API requests and stores the user id in the request context.
+// By default it verifies the access_token cookie (or an "Authorization: Bearer" header)
+// and ignores X-User-ID. With trustedGateway set the X-User-ID header is taken as is,
+// which is only safe when the service is reachable exclusively through the API gateway.
+func AuthHTTP(trustedGateway bool) func(http.Handler) http.Handler {
+	tokenKey := []byte(os.Getenv("CENTRIFUGO_TOKEN_HMAC_SECRET_KEY"))
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var userIDString string
+			if trustedGateway {
+				userIDString = r.Header.Get(USER_ID_HEADER)
+			} else {
+				tokenString := accessTokenFromRequest(r)
+				if tokenString == "" {
+					http.Error(w, "Unauthorized: no token", http.StatusUnauthorized)
+					return
+				}
+
+				var err error
+				userIDString, err = auth.ParseAccessToken(tokenString, tokenKey)
+				if err != nil {
+					fmt.Println("Unauthorized:", err)
+					http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+					return
+				}
+			}
+
+			userID, err := strconv.ParseUint(userIDString, 10, 32)
+			if err != nil || userID == 0 {
+				http.Error(w, "Unauthorized: invalid user ID", http.StatusUnauthorized)
+				return
+			}
+
+			r = r.WithContext(auth.WithUserID(r.Context(), uint(userID)))
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+func accessTokenFromRequest(r *http.Request) string {
+	if cookie, err := r.Cookie("access_token"); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+
+	authorization := r.Header.Get("Authorization")
+	if strings.HasPrefix(authorization, "Bearer ") {
+		return strings.TrimPrefix(authorization, "Bearer ")
+	}
+
+	return ""
+}
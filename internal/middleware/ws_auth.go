@@ -1,13 +1,12 @@
 package middleware
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
 	"os"
 
 	"github.com/centrifugal/centrifuge"
-	"github.com/dgrijalva/jwt-go"
+	"github.com/lightlink/group-service/internal/auth"
 )
 
 func ValidateAuthWS(h http.Handler) http.Handler {
@@ -22,37 +21,10 @@ func ValidateAuthWS(h http.Handler) http.Handler {
 		}
 		tokenString := cookie.Value
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			method, ok := token.Method.(*jwt.SigningMethodHMAC)
-			if !ok || method.Alg() != "HS256" {
-				return nil, errors.New("bad sign method")
-			}
-			return tokenKey, nil
-		})
-		if err != nil || !token.Valid {
-			fmt.Println("Token is invalid")
-			http.Error(w, "Token is invalid", http.StatusUnauthorized)
-			return
-		}
-
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			fmt.Println("User claims type cast err")
-			http.Error(w, "User claims type cast err", http.StatusUnauthorized)
-			return
-		}
-
-		claimsUser, ok := claims["user"].(map[string]interface{})
-		if !ok {
-			fmt.Println("User claims are missing")
-			http.Error(w, "User claims are missing", http.StatusUnauthorized)
-			return
-		}
-
-		userIDString, ok := claimsUser["id"].(string)
-		if !ok {
-			fmt.Println("Couldn't parse user id")
-			http.Error(w, "Couldn't parse user id", http.StatusUnauthorized)
+		userIDString, err := auth.ParseAccessToken(tokenString, tokenKey)
+		if err != nil {
+			fmt.Println(err)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
 			return
 		}
 
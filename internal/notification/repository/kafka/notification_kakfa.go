@@ -115,14 +115,20 @@ func (repo *NotificationKafkaRepository) Send(notification dto.RawNotification)
 		return fmt.Errorf("ошибка кодирования Avro: %v", err)
 	}
 
+	// Ждём подтверждения от брокера, чтобы outbox не считал событие доставленным раньше времени
+	deliveryChan := make(chan kafka.Event, 1)
 	err = repo.producer.Produce(&kafka.Message{
 		TopicPartition: kafka.TopicPartition{Topic: &repo.topic, Partition: kafka.PartitionAny},
 		Value:          avroData,
-	}, nil)
+	}, deliveryChan)
 	if err != nil {
 		return fmt.Errorf("ошибка отправки сообщения в Kafka: %v", err)
 	}
 
+	if delivered, ok := (<-deliveryChan).(*kafka.Message); ok && delivered.TopicPartition.Error != nil {
+		return fmt.Errorf("ошибка доставки сообщения в Kafka: %v", delivered.TopicPartition.Error)
+	}
+
 	fmt.Printf("KAFKA: Send value in queue: type-%s, payload-%v\n", notification.Type, payload)
 
 	return nil
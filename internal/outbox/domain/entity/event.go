@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"encoding/json"
+
+	messageDTO "github.com/lightlink/group-service/internal/message/domain/dto"
+	notificationDTO "github.com/lightlink/group-service/internal/notification/domain/dto"
+)
+
+const (
+	KIND_NOTIFICATION        = "notification"
+	KIND_HATE_SPEECH_REQUEST = "hate_speech_request"
+	KIND_GROUP_PUBLISH       = "group_publish"
+)
+
+// Event is a side effect stored together with the change that caused it
+// and delivered by the relay at least once.
+type Event struct {
+	ID       uint64
+	Kind     string
+	Payload  json.RawMessage
+	Attempts int
+}
+
+type GroupPublishPayload struct {
+	GroupID uint            `json:"group_id"`
+	Data    json.RawMessage `json:"data"`
+}
+
+func NewNotificationEvent(notification notificationDTO.RawNotification) (Event, error) {
+	return newEvent(KIND_NOTIFICATION, notification)
+}
+
+func NewHateSpeechRequestEvent(hateSpeechRequest messageDTO.MessageHateSpeechRequest) (Event, error) {
+	return newEvent(KIND_HATE_SPEECH_REQUEST, hateSpeechRequest)
+}
+
+func NewGroupPublishEvent(groupID uint, data interface{}) (Event, error) {
+	encodedData, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return newEvent(KIND_GROUP_PUBLISH, GroupPublishPayload{
+		GroupID: groupID,
+		Data:    encodedData,
+	})
+}
+
+func newEvent(kind string, payload interface{}) (Event, error) {
+	encodedPayload, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		Kind:    kind,
+		Payload: encodedPayload,
+	}, nil
+}
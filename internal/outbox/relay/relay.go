@@ -0,0 +1,179 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lightlink/group-service/infrastructure/ws"
+	messageDTO "github.com/lightlink/group-service/internal/message/domain/dto"
+	messageRepo "github.com/lightlink/group-service/internal/message/repository"
+	notificationDTO "github.com/lightlink/group-service/internal/notification/domain/dto"
+	notificationRepo "github.com/lightlink/group-service/internal/notification/repository"
+	"github.com/lightlink/group-service/internal/outbox/domain/entity"
+	outboxRepo "github.com/lightlink/group-service/internal/outbox/repository"
+)
+
+const (
+	POLL_INTERVAL       = time.Second
+	BATCH_SIZE          = 100
+	CLAIM_LEASE         = 30 * time.Second
+	MIN_RETRY_DELAY     = time.Second
+	MAX_RETRY_DELAY     = 5 * time.Minute
+	DELIVERED_RETENTION = 7 * 24 * time.Hour
+	CLEANUP_INTERVAL    = time.Hour
+	// MAX_ATTEMPTS bounds retries of transient failures; with the backoff above an event
+	// is retried for about an hour before it is moved to dead.
+	MAX_ATTEMPTS = 20
+)
+
+// errUndeliverable marks events that will never succeed, e.g. with a payload that does not decode.
+var errUndeliverable = errors.New("undeliverable outbox event")
+
+// Notifier wakes the relay right after new events are committed, so they do not wait
+// for the next poll.
+type Notifier interface {
+	Notify()
+}
+
+// Relay delivers outbox events to their sinks. Several relays may run against the
+// same table; a claimed event is invisible to the others until its lease expires.
+type Relay struct {
+	outboxRepo            outboxRepo.OutboxRepositoryI
+	notificationRepo      notificationRepo.NotificationRepositoryI
+	messageHateSpeechRepo messageRepo.MessageHateSpeechRepositoryI
+	messagingServer       ws.MessagingServer
+	wake                  chan struct{}
+}
+
+func NewRelay(
+	outboxRepo outboxRepo.OutboxRepositoryI,
+	notificationRepo notificationRepo.NotificationRepositoryI,
+	messageHateSpeechRepo messageRepo.MessageHateSpeechRepositoryI,
+	messagingServer ws.MessagingServer,
+) *Relay {
+	return &Relay{
+		outboxRepo:            outboxRepo,
+		notificationRepo:      notificationRepo,
+		messageHateSpeechRepo: messageHateSpeechRepo,
+		messagingServer:       messagingServer,
+		wake:                  make(chan struct{}, 1),
+	}
+}
+
+// Notify never blocks: a pending wake-up already covers events committed since.
+func (r *Relay) Notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (r *Relay) Run(ctx context.Context) {
+	pollTicker := time.NewTicker(POLL_INTERVAL)
+	defer pollTicker.Stop()
+
+	cleanupTicker := time.NewTicker(CLEANUP_INTERVAL)
+	defer cleanupTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-cleanupTicker.C:
+			if _, err := r.outboxRepo.DeleteDelivered(DELIVERED_RETENTION); err != nil {
+				log.Printf("ERR: Failed to clean up outbox: %v\n", err)
+			}
+		case <-r.wake:
+			r.drain(ctx)
+		case <-pollTicker.C:
+			r.drain(ctx)
+		}
+	}
+}
+
+// drain relays the backlog without waiting for the next tick.
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		if r.relayBatch() < BATCH_SIZE {
+			return
+		}
+	}
+}
+
+func (r *Relay) relayBatch() int {
+	events, err := r.outboxRepo.Claim(BATCH_SIZE, CLAIM_LEASE)
+	if err != nil {
+		log.Printf("ERR: Failed to claim outbox events: %v\n", err)
+		return 0
+	}
+
+	for _, event := range events {
+		err := r.deliver(event)
+		switch {
+		case err == nil:
+			err = r.outboxRepo.MarkDelivered(event.ID)
+		case errors.Is(err, errUndeliverable):
+			log.Printf("ERR: Dropping outbox event %d: %v\n", event.ID, err)
+			err = r.outboxRepo.MarkDead(event.ID, err)
+		case event.Attempts >= MAX_ATTEMPTS:
+			log.Printf("ERR: Giving up on outbox event %d after %d attempts: %v\n", event.ID, event.Attempts, err)
+			err = r.outboxRepo.MarkDead(event.ID, err)
+		default:
+			log.Printf("ERR: Failed to deliver outbox event %d (attempt %d): %v\n", event.ID, event.Attempts, err)
+			err = r.outboxRepo.MarkFailed(event.ID, err, retryDelay(event.Attempts))
+		}
+		if err != nil {
+			log.Printf("ERR: Failed to update outbox event %d: %v\n", event.ID, err)
+		}
+	}
+
+	return len(events)
+}
+
+func (r *Relay) deliver(event entity.Event) error {
+	switch event.Kind {
+	case entity.KIND_NOTIFICATION:
+		var notification notificationDTO.RawNotification
+		if err := json.Unmarshal(event.Payload, &notification); err != nil {
+			return fmt.Errorf("%w: %v", errUndeliverable, err)
+		}
+		return r.notificationRepo.Send(notification)
+
+	case entity.KIND_HATE_SPEECH_REQUEST:
+		var hateSpeechRequest messageDTO.MessageHateSpeechRequest
+		if err := json.Unmarshal(event.Payload, &hateSpeechRequest); err != nil {
+			return fmt.Errorf("%w: %v", errUndeliverable, err)
+		}
+		return r.messageHateSpeechRepo.Send(hateSpeechRequest)
+
+	case entity.KIND_GROUP_PUBLISH:
+		var publish entity.GroupPublishPayload
+		if err := json.Unmarshal(event.Payload, &publish); err != nil {
+			return fmt.Errorf("%w: %v", errUndeliverable, err)
+		}
+		// A fixed key keeps redeliveries of the same event from reaching clients twice.
+		idempotencyKey := fmt.Sprintf("outbox-%d", event.ID)
+		return r.messagingServer.PublishToGroupWithKey(publish.GroupID, publish.Data, idempotencyKey)
+
+	default:
+		return fmt.Errorf("%w: unknown kind %q", errUndeliverable, event.Kind)
+	}
+}
+
+// retryDelay doubles with every attempt, from MIN_RETRY_DELAY up to MAX_RETRY_DELAY.
+func retryDelay(attempts int) time.Duration {
+	delay := MIN_RETRY_DELAY
+	for i := 1; i < attempts && delay < MAX_RETRY_DELAY; i++ {
+		delay *= 2
+	}
+
+	if delay > MAX_RETRY_DELAY {
+		return MAX_RETRY_DELAY
+	}
+
+	return delay
+}
@@ -0,0 +1,118 @@
+package relay
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lightlink/group-service/infrastructure/ws"
+	"github.com/lightlink/group-service/internal/outbox/domain/entity"
+	outboxRepo "github.com/lightlink/group-service/internal/outbox/repository"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		want     time.Duration
+	}{
+		{name: "no attempts yet", attempts: 0, want: MIN_RETRY_DELAY},
+		{name: "first attempt", attempts: 1, want: MIN_RETRY_DELAY},
+		{name: "second attempt doubles", attempts: 2, want: 2 * MIN_RETRY_DELAY},
+		{name: "fifth attempt", attempts: 5, want: 16 * MIN_RETRY_DELAY},
+		{name: "last uncapped attempt", attempts: 9, want: 256 * MIN_RETRY_DELAY},
+		{name: "capped", attempts: 10, want: MAX_RETRY_DELAY},
+		{name: "capped at the attempt limit", attempts: MAX_ATTEMPTS, want: MAX_RETRY_DELAY},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryDelay(tt.attempts); got != tt.want {
+				t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+			}
+		})
+	}
+}
+
+// recordingOutboxRepo hands out one claimed event and records how it was resolved.
+type recordingOutboxRepo struct {
+	outboxRepo.OutboxRepositoryI
+	events     []entity.Event
+	outcome    string // "delivered", "failed" or "dead"
+	retryDelay time.Duration
+}
+
+func (r *recordingOutboxRepo) Claim(limit int, lease time.Duration) ([]entity.Event, error) {
+	return r.events, nil
+}
+
+func (r *recordingOutboxRepo) MarkDelivered(eventID uint64) error {
+	r.outcome = "delivered"
+	return nil
+}
+
+func (r *recordingOutboxRepo) MarkFailed(eventID uint64, deliveryErr error, retryDelay time.Duration) error {
+	r.outcome = "failed"
+	r.retryDelay = retryDelay
+	return nil
+}
+
+func (r *recordingOutboxRepo) MarkDead(eventID uint64, deliveryErr error) error {
+	r.outcome = "dead"
+	return nil
+}
+
+// publishingServer only answers PublishToGroupWithKey, the method the relay uses.
+type publishingServer struct {
+	ws.MessagingServer
+	err error
+}
+
+func (s publishingServer) PublishToGroupWithKey(groupID uint, data interface{}, idempotencyKey string) error {
+	return s.err
+}
+
+func TestRelayBatchOutcome(t *testing.T) {
+	errPublish := errors.New("centrifugo is down")
+
+	tests := []struct {
+		name           string
+		kind           string
+		attempts       int
+		publishErr     error
+		wantOutcome    string
+		wantRetryDelay time.Duration
+	}{
+		{name: "delivered", kind: entity.KIND_GROUP_PUBLISH, attempts: 1, wantOutcome: "delivered"},
+		{name: "first failure is retried", kind: entity.KIND_GROUP_PUBLISH, attempts: 1, publishErr: errPublish, wantOutcome: "failed", wantRetryDelay: MIN_RETRY_DELAY},
+		{name: "failure below the limit is retried", kind: entity.KIND_GROUP_PUBLISH, attempts: MAX_ATTEMPTS - 1, publishErr: errPublish, wantOutcome: "failed", wantRetryDelay: MAX_RETRY_DELAY},
+		{name: "failure at the limit is dead", kind: entity.KIND_GROUP_PUBLISH, attempts: MAX_ATTEMPTS, publishErr: errPublish, wantOutcome: "dead"},
+		{name: "success at the limit is delivered", kind: entity.KIND_GROUP_PUBLISH, attempts: MAX_ATTEMPTS, wantOutcome: "delivered"},
+		{name: "unknown kind is dead at once", kind: "unknown", attempts: 1, wantOutcome: "dead"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := entity.NewGroupPublishEvent(7, map[string]string{"type": "newMessage"})
+			if err != nil {
+				t.Fatalf("NewGroupPublishEvent() error = %v", err)
+			}
+			event.ID = 1
+			event.Kind = tt.kind
+			event.Attempts = tt.attempts
+
+			repo := &recordingOutboxRepo{events: []entity.Event{event}}
+			relay := NewRelay(repo, nil, nil, publishingServer{err: tt.publishErr})
+
+			if got := relay.relayBatch(); got != 1 {
+				t.Fatalf("relayBatch() = %d, want 1", got)
+			}
+			if repo.outcome != tt.wantOutcome {
+				t.Errorf("outcome = %q, want %q", repo.outcome, tt.wantOutcome)
+			}
+			if repo.retryDelay != tt.wantRetryDelay {
+				t.Errorf("retry delay = %v, want %v", repo.retryDelay, tt.wantRetryDelay)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lightlink/group-service/internal/outbox/domain/entity"
+)
+
+type OutboxPostgresRepository struct {
+	DB *sql.DB
+}
+
+func NewOutboxPostgresRepository(db *sql.DB) *OutboxPostgresRepository {
+	return &OutboxPostgresRepository{
+		DB: db,
+	}
+}
+
+// Insert stores events in the caller's transaction, so they are committed
+// or rolled back together with the change that produced them.
+func Insert(tx *sql.Tx, events []entity.Event) error {
+	for _, event := range events {
+		_, err := tx.Exec(`
+            INSERT INTO outbox_events (kind, payload)
+            VALUES ($1, $2)`,
+			event.Kind, []byte(event.Payload),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert outbox event: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (repo *OutboxPostgresRepository) Claim(limit int, lease time.Duration) ([]entity.Event, error) {
+	rows, err := repo.DB.Query(`
+        UPDATE outbox_events
+        SET attempts = attempts + 1, next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+        WHERE id IN (
+            SELECT id FROM outbox_events
+            WHERE delivered_at IS NULL AND failed_at IS NULL AND next_attempt_at <= NOW()
+            ORDER BY id
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING id, kind, payload, attempts`,
+		limit, lease.Milliseconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []entity.Event
+	for rows.Next() {
+		var event entity.Event
+		var payload []byte
+		if err := rows.Scan(&event.ID, &event.Kind, &payload, &event.Attempts); err != nil {
+			return nil, err
+		}
+		event.Payload = payload
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (repo *OutboxPostgresRepository) MarkDelivered(eventID uint64) error {
+	_, err := repo.DB.Exec(`
+        UPDATE outbox_events
+        SET delivered_at = NOW(), last_error = NULL
+        WHERE id = $1`,
+		eventID,
+	)
+	return err
+}
+
+func (repo *OutboxPostgresRepository) MarkFailed(eventID uint64, deliveryErr error, retryDelay time.Duration) error {
+	_, err := repo.DB.Exec(`
+        UPDATE outbox_events
+        SET last_error = $2, next_attempt_at = NOW() + $3 * INTERVAL '1 millisecond'
+        WHERE id = $1`,
+		eventID, deliveryErr.Error(), retryDelay.Milliseconds(),
+	)
+	return err
+}
+
+func (repo *OutboxPostgresRepository) MarkDead(eventID uint64, deliveryErr error) error {
+	_, err := repo.DB.Exec(`
+        UPDATE outbox_events
+        SET failed_at = NOW(), last_error = $2
+        WHERE id = $1`,
+		eventID, deliveryErr.Error(),
+	)
+	return err
+}
+
+func (repo *OutboxPostgresRepository) DeleteDelivered(olderThan time.Duration) (int64, error) {
+	result, err := repo.DB.Exec(`
+        DELETE FROM outbox_events
+        WHERE delivered_at IS NOT NULL AND delivered_at < NOW() - $1 * INTERVAL '1 millisecond'`,
+		olderThan.Milliseconds(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/lightlink/group-service/internal/outbox/domain/entity"
+)
+
+type OutboxRepositoryI interface {
+	// Claim locks up to limit due events for the lease duration and counts the attempt.
+	// Events of a relay that dies mid-delivery become due again once the lease expires.
+	Claim(limit int, lease time.Duration) ([]entity.Event, error)
+	MarkDelivered(eventID uint64) error
+	MarkFailed(eventID uint64, deliveryErr error, retryDelay time.Duration) error
+	MarkDead(eventID uint64, deliveryErr error) error
+	DeleteDelivered(olderThan time.Duration) (int64, error)
+}
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type LastSeenPostgresRepository struct {
+	DB *sql.DB
+}
+
+func NewLastSeenPostgresRepository(db *sql.DB) *LastSeenPostgresRepository {
+	return &LastSeenPostgresRepository{
+		DB: db,
+	}
+}
+
+func (repo *LastSeenPostgresRepository) Touch(userID uint) error {
+	_, err := repo.DB.Exec(`
+        INSERT INTO user_last_seen (user_id, last_seen_at)
+        VALUES ($1, NOW())
+        ON CONFLICT (user_id) DO UPDATE SET last_seen_at = EXCLUDED.last_seen_at`,
+		userID,
+	)
+	return err
+}
+
+func (repo *LastSeenPostgresRepository) GetLastSeen(userIDs []uint) (map[uint]time.Time, error) {
+	lastSeen := make(map[uint]time.Time, len(userIDs))
+	if len(userIDs) == 0 {
+		return lastSeen, nil
+	}
+
+	ids := make([]int64, 0, len(userIDs))
+	for _, userID := range userIDs {
+		ids = append(ids, int64(userID))
+	}
+
+	rows, err := repo.DB.Query(`
+        SELECT user_id, last_seen_at
+        FROM user_last_seen
+        WHERE user_id = ANY($1)`,
+		pq.Array(ids),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID uint
+		var lastSeenAt time.Time
+		if err := rows.Scan(&userID, &lastSeenAt); err != nil {
+			return nil, err
+		}
+		lastSeen[userID] = lastSeenAt
+	}
+
+	return lastSeen, rows.Err()
+}
@@ -0,0 +1,9 @@
+package repository
+
+import "time"
+
+type LastSeenRepositoryI interface {
+	// Touch records that the user is online right now.
+	Touch(userID uint) error
+	GetLastSeen(userIDs []uint) (map[uint]time.Time, error)
+}
@@ -19,7 +19,15 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	GroupService_CreatePersonalGroup_FullMethodName = "/group.GroupService/CreatePersonalGroup"
+	GroupService_CreatePersonalGroup_FullMethodName      = "/group.GroupService/CreatePersonalGroup"
+	GroupService_GetOrCreatePersonalGroup_FullMethodName = "/group.GroupService/GetOrCreatePersonalGroup"
+	GroupService_GetGroup_FullMethodName                 = "/group.GroupService/GetGroup"
+	GroupService_ListGroupsForUser_FullMethodName        = "/group.GroupService/ListGroupsForUser"
+	GroupService_ListMembers_FullMethodName              = "/group.GroupService/ListMembers"
+	GroupService_IsMember_FullMethodName                 = "/group.GroupService/IsMember"
+	GroupService_GetPersonalGroupID_FullMethodName       = "/group.GroupService/GetPersonalGroupID"
+	GroupService_AddMembers_FullMethodName               = "/group.GroupService/AddMembers"
+	GroupService_SubscribeGroupEvents_FullMethodName     = "/group.GroupService/SubscribeGroupEvents"
 )
 
 // GroupServiceClient is the client API for GroupService service.
@@ -29,6 +37,14 @@ const (
 // protoc --go_opt=paths=source_relative --go-grpc_opt=paths=source_relative --proto_path=proto --go_out=protogen --go-grpc_out=protogen proto/group/group.proto
 type GroupServiceClient interface {
 	CreatePersonalGroup(ctx context.Context, in *CreatePersonalGroupRequest, opts ...grpc.CallOption) (*CreatePersonalGroupResponse, error)
+	GetOrCreatePersonalGroup(ctx context.Context, in *GetOrCreatePersonalGroupRequest, opts ...grpc.CallOption) (*GetOrCreatePersonalGroupResponse, error)
+	GetGroup(ctx context.Context, in *GetGroupRequest, opts ...grpc.CallOption) (*GetGroupResponse, error)
+	ListGroupsForUser(ctx context.Context, in *ListGroupsForUserRequest, opts ...grpc.CallOption) (*ListGroupsForUserResponse, error)
+	ListMembers(ctx context.Context, in *ListMembersRequest, opts ...grpc.CallOption) (*ListMembersResponse, error)
+	IsMember(ctx context.Context, in *IsMemberRequest, opts ...grpc.CallOption) (*IsMemberResponse, error)
+	GetPersonalGroupID(ctx context.Context, in *GetPersonalGroupIDRequest, opts ...grpc.CallOption) (*GetPersonalGroupIDResponse, error)
+	AddMembers(ctx context.Context, in *AddMembersRequest, opts ...grpc.CallOption) (*AddMembersResponse, error)
+	SubscribeGroupEvents(ctx context.Context, in *SubscribeGroupEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GroupEvent], error)
 }
 
 type groupServiceClient struct {
@@ -49,6 +65,95 @@ func (c *groupServiceClient) CreatePersonalGroup(ctx context.Context, in *Create
 	return out, nil
 }
 
+func (c *groupServiceClient) GetOrCreatePersonalGroup(ctx context.Context, in *GetOrCreatePersonalGroupRequest, opts ...grpc.CallOption) (*GetOrCreatePersonalGroupResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetOrCreatePersonalGroupResponse)
+	err := c.cc.Invoke(ctx, GroupService_GetOrCreatePersonalGroup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *groupServiceClient) GetGroup(ctx context.Context, in *GetGroupRequest, opts ...grpc.CallOption) (*GetGroupResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetGroupResponse)
+	err := c.cc.Invoke(ctx, GroupService_GetGroup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *groupServiceClient) ListGroupsForUser(ctx context.Context, in *ListGroupsForUserRequest, opts ...grpc.CallOption) (*ListGroupsForUserResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListGroupsForUserResponse)
+	err := c.cc.Invoke(ctx, GroupService_ListGroupsForUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *groupServiceClient) ListMembers(ctx context.Context, in *ListMembersRequest, opts ...grpc.CallOption) (*ListMembersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListMembersResponse)
+	err := c.cc.Invoke(ctx, GroupService_ListMembers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *groupServiceClient) IsMember(ctx context.Context, in *IsMemberRequest, opts ...grpc.CallOption) (*IsMemberResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IsMemberResponse)
+	err := c.cc.Invoke(ctx, GroupService_IsMember_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *groupServiceClient) GetPersonalGroupID(ctx context.Context, in *GetPersonalGroupIDRequest, opts ...grpc.CallOption) (*GetPersonalGroupIDResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetPersonalGroupIDResponse)
+	err := c.cc.Invoke(ctx, GroupService_GetPersonalGroupID_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *groupServiceClient) AddMembers(ctx context.Context, in *AddMembersRequest, opts ...grpc.CallOption) (*AddMembersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddMembersResponse)
+	err := c.cc.Invoke(ctx, GroupService_AddMembers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *groupServiceClient) SubscribeGroupEvents(ctx context.Context, in *SubscribeGroupEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GroupEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GroupService_ServiceDesc.Streams[0], GroupService_SubscribeGroupEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeGroupEventsRequest, GroupEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GroupService_SubscribeGroupEventsClient = grpc.ServerStreamingClient[GroupEvent]
+
 // GroupServiceServer is the server API for GroupService service.
 // All implementations must embed UnimplementedGroupServiceServer
 // for forward compatibility.
@@ -56,6 +161,14 @@ func (c *groupServiceClient) CreatePersonalGroup(ctx context.Context, in *Create
 // protoc --go_opt=paths=source_relative --go-grpc_opt=paths=source_relative --proto_path=proto --go_out=protogen --go-grpc_out=protogen proto/group/group.proto
 type GroupServiceServer interface {
 	CreatePersonalGroup(context.Context, *CreatePersonalGroupRequest) (*CreatePersonalGroupResponse, error)
+	GetOrCreatePersonalGroup(context.Context, *GetOrCreatePersonalGroupRequest) (*GetOrCreatePersonalGroupResponse, error)
+	GetGroup(context.Context, *GetGroupRequest) (*GetGroupResponse, error)
+	ListGroupsForUser(context.Context, *ListGroupsForUserRequest) (*ListGroupsForUserResponse, error)
+	ListMembers(context.Context, *ListMembersRequest) (*ListMembersResponse, error)
+	IsMember(context.Context, *IsMemberRequest) (*IsMemberResponse, error)
+	GetPersonalGroupID(context.Context, *GetPersonalGroupIDRequest) (*GetPersonalGroupIDResponse, error)
+	AddMembers(context.Context, *AddMembersRequest) (*AddMembersResponse, error)
+	SubscribeGroupEvents(*SubscribeGroupEventsRequest, grpc.ServerStreamingServer[GroupEvent]) error
 	mustEmbedUnimplementedGroupServiceServer()
 }
 
@@ -69,6 +182,30 @@ type UnimplementedGroupServiceServer struct{}
 func (UnimplementedGroupServiceServer) CreatePersonalGroup(context.Context, *CreatePersonalGroupRequest) (*CreatePersonalGroupResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreatePersonalGroup not implemented")
 }
+func (UnimplementedGroupServiceServer) GetOrCreatePersonalGroup(context.Context, *GetOrCreatePersonalGroupRequest) (*GetOrCreatePersonalGroupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrCreatePersonalGroup not implemented")
+}
+func (UnimplementedGroupServiceServer) GetGroup(context.Context, *GetGroupRequest) (*GetGroupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGroup not implemented")
+}
+func (UnimplementedGroupServiceServer) ListGroupsForUser(context.Context, *ListGroupsForUserRequest) (*ListGroupsForUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListGroupsForUser not implemented")
+}
+func (UnimplementedGroupServiceServer) ListMembers(context.Context, *ListMembersRequest) (*ListMembersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMembers not implemented")
+}
+func (UnimplementedGroupServiceServer) IsMember(context.Context, *IsMemberRequest) (*IsMemberResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IsMember not implemented")
+}
+func (UnimplementedGroupServiceServer) GetPersonalGroupID(context.Context, *GetPersonalGroupIDRequest) (*GetPersonalGroupIDResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPersonalGroupID not implemented")
+}
+func (UnimplementedGroupServiceServer) AddMembers(context.Context, *AddMembersRequest) (*AddMembersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddMembers not implemented")
+}
+func (UnimplementedGroupServiceServer) SubscribeGroupEvents(*SubscribeGroupEventsRequest, grpc.ServerStreamingServer[GroupEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeGroupEvents not implemented")
+}
 func (UnimplementedGroupServiceServer) mustEmbedUnimplementedGroupServiceServer() {}
 func (UnimplementedGroupServiceServer) testEmbeddedByValue()                      {}
 
@@ -108,6 +245,143 @@ func _GroupService_CreatePersonalGroup_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _GroupService_GetOrCreatePersonalGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrCreatePersonalGroupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GroupServiceServer).GetOrCreatePersonalGroup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GroupService_GetOrCreatePersonalGroup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GroupServiceServer).GetOrCreatePersonalGroup(ctx, req.(*GetOrCreatePersonalGroupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GroupService_GetGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetGroupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GroupServiceServer).GetGroup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GroupService_GetGroup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GroupServiceServer).GetGroup(ctx, req.(*GetGroupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GroupService_ListGroupsForUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListGroupsForUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GroupServiceServer).ListGroupsForUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GroupService_ListGroupsForUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GroupServiceServer).ListGroupsForUser(ctx, req.(*ListGroupsForUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GroupService_ListMembers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMembersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GroupServiceServer).ListMembers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GroupService_ListMembers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GroupServiceServer).ListMembers(ctx, req.(*ListMembersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GroupService_IsMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IsMemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GroupServiceServer).IsMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GroupService_IsMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GroupServiceServer).IsMember(ctx, req.(*IsMemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GroupService_GetPersonalGroupID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPersonalGroupIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GroupServiceServer).GetPersonalGroupID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GroupService_GetPersonalGroupID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GroupServiceServer).GetPersonalGroupID(ctx, req.(*GetPersonalGroupIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GroupService_AddMembers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddMembersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GroupServiceServer).AddMembers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GroupService_AddMembers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GroupServiceServer).AddMembers(ctx, req.(*AddMembersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GroupService_SubscribeGroupEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeGroupEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GroupServiceServer).SubscribeGroupEvents(m, &grpc.GenericServerStream[SubscribeGroupEventsRequest, GroupEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GroupService_SubscribeGroupEventsServer = grpc.ServerStreamingServer[GroupEvent]
+
 // GroupService_ServiceDesc is the grpc.ServiceDesc for GroupService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -119,7 +393,41 @@ var GroupService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CreatePersonalGroup",
 			Handler:    _GroupService_CreatePersonalGroup_Handler,
 		},
+		{
+			MethodName: "GetOrCreatePersonalGroup",
+			Handler:    _GroupService_GetOrCreatePersonalGroup_Handler,
+		},
+		{
+			MethodName: "GetGroup",
+			Handler:    _GroupService_GetGroup_Handler,
+		},
+		{
+			MethodName: "ListGroupsForUser",
+			Handler:    _GroupService_ListGroupsForUser_Handler,
+		},
+		{
+			MethodName: "ListMembers",
+			Handler:    _GroupService_ListMembers_Handler,
+		},
+		{
+			MethodName: "IsMember",
+			Handler:    _GroupService_IsMember_Handler,
+		},
+		{
+			MethodName: "GetPersonalGroupID",
+			Handler:    _GroupService_GetPersonalGroupID_Handler,
+		},
+		{
+			MethodName: "AddMembers",
+			Handler:    _GroupService_AddMembers_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeGroupEvents",
+			Handler:       _GroupService_SubscribeGroupEvents_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "group/group.proto",
 }
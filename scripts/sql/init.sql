@@ -13,21 +13,41 @@ CREATE TABLE IF NOT EXISTS groups (
     name VARCHAR(255) NOT NULL,
     creator_id INTEGER NOT NULL,
     type_id INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
     CONSTRAINT fk_group_type FOREIGN KEY (type_id) REFERENCES group_types(id)
 );
 
+-- Databases created before created_at existed.
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT NOW();
+
 CREATE TABLE IF NOT EXISTS group_members (
     user_id INTEGER NOT NULL,
     group_id INTEGER NOT NULL,
     role_id INTEGER NOT NULL,
+    last_read_message_id INTEGER,
     CONSTRAINT pk_group_member PRIMARY KEY (user_id, group_id),
     CONSTRAINT fk_group FOREIGN KEY (group_id) REFERENCES groups(id),
     CONSTRAINT fk_role FOREIGN KEY (role_id) REFERENCES roles(id)
 );
 
+-- Databases created before read markers existed.
+ALTER TABLE group_members ADD COLUMN IF NOT EXISTS last_read_message_id INTEGER;
+
+-- Unordered user pair of each personal group; the pair is stored with the smaller id first.
+CREATE TABLE IF NOT EXISTS personal_groups (
+    group_id INTEGER PRIMARY KEY,
+    user_low_id INTEGER NOT NULL,
+    user_high_id INTEGER NOT NULL,
+    CONSTRAINT fk_personal_group FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+    CONSTRAINT uq_personal_group_pair UNIQUE (user_low_id, user_high_id),
+    CONSTRAINT chk_personal_group_pair CHECK (user_low_id < user_high_id)
+);
+
 INSERT INTO roles (name) VALUES
     ('admin'),
-    ('member')
+    ('moderator'),
+    ('member'),
+    ('reader')
 ON CONFLICT (name) DO NOTHING;
 
 INSERT INTO group_types (name) VALUES
@@ -35,6 +55,18 @@ INSERT INTO group_types (name) VALUES
     ('group')
 ON CONFLICT (name) DO NOTHING;
 
+-- Register personal groups created before personal_groups existed; duplicates keep the oldest group.
+INSERT INTO personal_groups (group_id, user_low_id, user_high_id)
+SELECT g.id, MIN(gm.user_id), MAX(gm.user_id)
+FROM groups g
+JOIN group_types gt ON g.type_id = gt.id
+JOIN group_members gm ON g.id = gm.group_id
+WHERE gt.name = 'personal'
+GROUP BY g.id
+HAVING COUNT(*) = 2 AND MIN(gm.user_id) < MAX(gm.user_id)
+ORDER BY g.id
+ON CONFLICT DO NOTHING;
+
 CREATE TABLE IF NOT EXISTS message_statuses (
     id SERIAL PRIMARY KEY, 
     name VARCHAR(255) NOT NULL UNIQUE
@@ -47,11 +79,58 @@ CREATE TABLE IF NOT EXISTS messages (
     status_id INTEGER NOT NULL,
     content TEXT NOT NULL,
     created_at TIMESTAMP NOT NULL DEFAULT NOW(),
-    CONSTRAINT fk_message_user FOREIGN KEY (user_id, group_id) REFERENCES group_members(user_id, group_id),
+    edited_at TIMESTAMP,
+    deleted_at TIMESTAMP,
+    reply_to_message_id INTEGER,
+    CONSTRAINT uq_message_group UNIQUE (id, group_id),
     CONSTRAINT fk_message_group FOREIGN KEY (group_id) REFERENCES groups(id),
-    CONSTRAINT fk_message_status FOREIGN KEY (status_id) REFERENCES message_statuses(id)
+    CONSTRAINT fk_message_status FOREIGN KEY (status_id) REFERENCES message_statuses(id),
+    -- A reply must point to a message of the same group.
+    CONSTRAINT fk_message_reply FOREIGN KEY (reply_to_message_id, group_id) REFERENCES messages(id, group_id)
 );
 
+-- Databases created before edits and deletion existed.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS edited_at TIMESTAMP;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- Bumped by every edit; hate speech verdicts for an older version are ignored.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS content_version INTEGER NOT NULL DEFAULT 0;
+
+-- Databases created before replies existed.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS reply_to_message_id INTEGER;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'uq_message_group') THEN
+        ALTER TABLE messages ADD CONSTRAINT uq_message_group UNIQUE (id, group_id);
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_message_reply') THEN
+        ALTER TABLE messages ADD CONSTRAINT fk_message_reply
+            FOREIGN KEY (reply_to_message_id, group_id) REFERENCES messages(id, group_id);
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_messages_reply_to ON messages(reply_to_message_id);
+CREATE INDEX IF NOT EXISTS idx_messages_group_id ON messages(group_id, id);
+
+-- Members can be removed from a group while their messages stay in its history,
+-- so the author is no longer required to be a current member.
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS fk_message_user;
+
+-- Sync cursor: every change to a group's messages takes the group's next change_seq while holding
+-- the group row lock, so one group's changes commit in change_seq order and a cursor never skips one.
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS change_seq BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS change_seq BIGINT NOT NULL DEFAULT 0;
+
+-- Messages written before change_seq existed are ordered by id.
+UPDATE messages SET change_seq = id WHERE change_seq = 0;
+UPDATE groups g SET change_seq = m.max_id
+FROM (SELECT group_id, MAX(id) AS max_id FROM messages GROUP BY group_id) m
+WHERE g.id = m.group_id AND g.change_seq < m.max_id;
+
+CREATE INDEX IF NOT EXISTS idx_messages_group_change_seq ON messages(group_id, change_seq);
+
 INSERT INTO message_statuses (name) VALUES
     ('pending'),
     ('neutral'),
@@ -68,3 +147,31 @@ CREATE TABLE IF NOT EXISTS files (
     url TEXT NOT NULL DEFAULT '',
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
+
+CREATE TABLE IF NOT EXISTS message_reactions (
+    message_id INTEGER NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL,
+    emoji VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT pk_message_reaction PRIMARY KEY (message_id, user_id, emoji)
+);
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMP,
+    failed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events(next_attempt_at, id)
+    WHERE delivered_at IS NULL AND failed_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS user_last_seen (
+    user_id INTEGER PRIMARY KEY,
+    last_seen_at TIMESTAMP NOT NULL DEFAULT NOW()
+);